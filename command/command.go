@@ -3,6 +3,7 @@ package command
 import (
 	"flag"
 	"fmt"
+	"os"
 	"strings"
 )
 
@@ -13,20 +14,29 @@ var Commands = []*Command{
 	cmdAura,
 	cmdBigObject,
 	cmdBulk,
+	cmdConvert,
 	cmdCreate,
 	cmdDataPipe,
+	cmdDeploy,
 	cmdDescribe,
+	cmdDiff,
 	cmdEventLogFile,
 	cmdExport,
 	cmdFetch,
 	cmdField,
+	cmdFormat,
 	cmdHelp,
 	cmdImport,
+	cmdLead,
 	cmdLimits,
 	cmdLog,
 	cmdLogin,
 	cmdLogins,
 	cmdLogout,
+	cmdLwc,
+	cmdManifest,
+	cmdMetadata,
+	cmdMirror,
 	cmdNotifySet,
 	cmdOauth,
 	cmdOpen,
@@ -36,13 +46,17 @@ var Commands = []*Command{
 	cmdQuery,
 	cmdQuickDeploy,
 	cmdRecord,
+	cmdRecycleBin,
 	cmdRest,
+	cmdRollback,
 	cmdSecurity,
 	cmdSobject,
 	cmdTest,
+	cmdTestSuite,
 	cmdTrace,
 	cmdUseDXAuth,
 	cmdVersion,
+	cmdWatch,
 	cmdWhoami,
 }
 
@@ -85,3 +99,21 @@ func (c *Command) InvalidInvocation(args []string) {
 	fmt.Printf("Invalid invocation: force %s\n\n", strings.Join(args, " "))
 	c.PrintUsage()
 }
+
+// Parse the flags of a command given after a sub-command, allowing flags
+// and positional arguments to be mixed.  The positional arguments are
+// returned.
+func parseSubcommandFlags(c *Command, args []string) (positional []string) {
+	for {
+		if err := c.Flag.Parse(args); err != nil {
+			c.InvalidInvocation(args)
+			os.Exit(2)
+		}
+		args = c.Flag.Args()
+		if len(args) == 0 {
+			return
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
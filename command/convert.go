@@ -0,0 +1,152 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ForceCLI/force/config"
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdConvert = &Command{
+	Run:   runConvert,
+	Usage: "convert -to <mdapi|source> [-d <output directory>] [<source directory>]",
+	Short: "Convert metadata between the metadata and source formats",
+	Long: `
+Convert metadata between the metadata format used by the Metadata API and the
+source format used by Salesforce DX projects
+
+In the source format, custom objects are split into a directory per object
+with a file for each field, list view, record type, etc., and metadata without
+a separate content file is stored with a -meta.xml suffix.
+
+The source directory defaults to the project's metadata directory.  The output
+directory defaults to src when converting to the metadata format and
+force-app/main/default when converting to the source format.  A package.xml
+is created when converting to the metadata format if the source directory does
+not have one.
+
+Options
+  -to             Format to convert to: mdapi or source
+  -d, -directory  Directory to write the converted metadata to
+
+Examples:
+
+  force convert -to source
+
+  force convert -to mdapi -d build/src force-app/main/default
+`,
+	MaxExpectedArgs: 1,
+}
+
+var (
+	convertTo        string
+	convertDirectory string
+)
+
+func init() {
+	cmdConvert.Flag.StringVar(&convertTo, "to", "", "format to convert to (mdapi or source)")
+	cmdConvert.Flag.StringVar(&convertDirectory, "d", "", "output directory")
+	cmdConvert.Flag.StringVar(&convertDirectory, "directory", "", "output directory")
+}
+
+func runConvert(cmd *Command, args []string) {
+	var convert func(ForceMetadataFiles) (ForceMetadataFiles, error)
+	defaultDirectory := ""
+	switch strings.ToLower(convertTo) {
+	case "mdapi":
+		convert = ConvertToMetadataFormat
+		defaultDirectory = "src"
+	case "source":
+		convert = ConvertToSourceFormat
+		defaultDirectory = filepath.Join("force-app", "main", "default")
+	default:
+		ErrorAndExit("must specify -to mdapi or -to source")
+	}
+
+	var root string
+	var err error
+	if len(args) == 1 {
+		root = args[0]
+	} else {
+		root, err = config.GetSourceDir()
+		ExitIfNoSourceDir(err)
+	}
+	dest := convertDirectory
+	if dest == "" {
+		dest = defaultDirectory
+	}
+	if sameDirectory(root, dest) {
+		ErrorAndExit("The output directory must be different from the source directory.")
+	}
+
+	files, err := readMetadataDir(root)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	converted, err := convert(files)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	if _, found := converted["package.xml"]; !found && strings.ToLower(convertTo) == "mdapi" {
+		converted["package.xml"] = PackageXmlForFiles(converted)
+	}
+	if err = writeMetadataDir(dest, converted); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	fmt.Printf("Converted %s to %s\n", root, dest)
+}
+
+func sameDirectory(a, b string) bool {
+	a, _ = filepath.Abs(a)
+	b, _ = filepath.Abs(b)
+	if ea, err := filepath.EvalSymlinks(a); err == nil {
+		a = ea
+	}
+	if eb, err := filepath.EvalSymlinks(b); err == nil {
+		b = eb
+	}
+	return a == b
+}
+
+// Read all of the files in a directory, keyed by their path relative to the
+// directory
+func readMetadataDir(root string) (files ForceMetadataFiles, err error) {
+	files = make(ForceMetadataFiles)
+	err = filepath.Walk(root, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if f.IsDir() || strings.ToLower(f.Name()) == ".ds_store" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	return
+}
+
+func writeMetadataDir(root string, files ForceMetadataFiles) (err error) {
+	for name, data := range files {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return
+		}
+		if err = ioutil.WriteFile(file, data, 0644); err != nil {
+			return
+		}
+	}
+	return
+}
@@ -2,18 +2,26 @@ package command
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/ForceCLI/force/config"
 	. "github.com/ForceCLI/force/error"
 	. "github.com/ForceCLI/force/lib"
 )
 
 var cmdCreate = &Command{
-	Usage: "create --type <ApexClass, ApexPage, ApexComponent, ApexTrigger> --name <item name> [--sobject <trigger object>]",
-	Short: "Creates a new, empty Apex Class, Trigger, Visualforce page, or Component.",
+	Usage: "create --type <ApexClass, ApexPage, ApexComponent, ApexTrigger, lwc> --name <item name> [--sobject <trigger object>]",
+	Short: "Creates a new, empty Apex Class, Trigger, Visualforce page, Component, or Lightning Web Component.",
 	Long: `
 Creates a new, empty Apex Class, Trigger, Visualforce page, or Component.
 
+Lightning Web Components are created locally in the lwc directory, with a js,
+html, and js-meta.xml file, and can be deployed using "force push".
+
 Examples:
 
   force create -t ApexClass -n NewController
@@ -23,6 +31,8 @@ Examples:
   force create -t ApexPage -n CoolPage
 
   force create -t ApexComponent -n CoolComponent
+
+  force create -t lwc -n coolComponent
 `,
 	MaxExpectedArgs: 0,
 }
@@ -44,34 +54,41 @@ func init() {
 	cmdCreate.Run = runCreate
 }
 
+var lwcNamePattern = regexp.MustCompile(`^[a-z][A-Za-z0-9]*(_[A-Za-z0-9]+)*$`)
+
 func runCreate(cmd *Command, args []string) {
-	force, _ := ActiveForce()
 	if len(what) == 0 || len(itemName) == 0 {
 		cmd.PrintUsage()
-	} else {
-		attrs := make(map[string]string)
-		switch strings.ToLower(what) {
-		case "apexclass":
-			attrs = getApexDefinition()
-		case "apextrigger":
-			if len(sObjectName) == 0 {
-				cmd.PrintUsage()
-				return
-			}
-			attrs = getTriggerDefinition()
-		case "apexcomponent":
-			attrs = getVFComponentDefinition()
-		case "visualforce", "apexpage":
-			what = "apexpage"
-			attrs = getVFDefinition()
+		return
+	}
+	switch strings.ToLower(what) {
+	case "lwc", "lightningcomponentbundle":
+		createLightningComponentBundle()
+		return
+	}
+	force, _ := ActiveForce()
+	attrs := make(map[string]string)
+	switch strings.ToLower(what) {
+	case "apexclass":
+		attrs = getApexDefinition()
+	case "apextrigger":
+		if len(sObjectName) == 0 {
+			cmd.PrintUsage()
+			return
 		}
+		attrs = getTriggerDefinition()
+	case "apexcomponent":
+		attrs = getVFComponentDefinition()
+	case "visualforce", "apexpage":
+		what = "apexpage"
+		attrs = getVFDefinition()
+	}
 
-		_, err := force.CreateToolingRecord(what, attrs)
-		if err != nil {
-			ErrorAndExit(fmt.Sprintf("Failed to create %s %s: %s", itemName, what, err.Error()))
-		} else {
-			fmt.Printf("Created new %s named %s.\n", what, itemName)
-		}
+	_, err := force.CreateToolingRecord(what, attrs)
+	if err != nil {
+		ErrorAndExit(fmt.Sprintf("Failed to create %s %s: %s", itemName, what, err.Error()))
+	} else {
+		fmt.Printf("Created new %s named %s.\n", what, itemName)
 	}
 }
 
@@ -107,3 +124,42 @@ func getTriggerDefinition() (attrs map[string]string) {
 	attrs["TableEnumOrId"] = sObjectName
 	return
 }
+
+// Create the files for a new Lightning Web Component in the lwc directory
+func createLightningComponentBundle() {
+	if !lwcNamePattern.MatchString(itemName) {
+		ErrorAndExit("Lightning Web Component names must begin with a lowercase letter and contain only letters, numbers, and single underscores.")
+	}
+	root, err := config.GetSourceDir()
+	ExitIfNoSourceDir(err)
+	dir := filepath.Join(root, "lwc", itemName)
+	if _, err := os.Stat(dir); err == nil {
+		ErrorAndExit("%s already exists", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	for name, contents := range lightningComponentBundleFiles(itemName, ApiVersionNumber()) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			ErrorAndExit(err.Error())
+		}
+	}
+	fmt.Printf("Created new Lightning Web Component named %s in %s.\n", itemName, dir)
+}
+
+func lightningComponentBundleFiles(name string, apiVersion string) (files map[string]string) {
+	className := strings.ToUpper(name[:1]) + name[1:]
+	files = make(map[string]string)
+	files[name+".js"] = fmt.Sprintf(`import { LightningElement } from 'lwc';
+
+export default class %s extends LightningElement {}
+`, className)
+	files[name+".html"] = "<template>\n</template>\n"
+	files[name+".js-meta.xml"] = fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<LightningComponentBundle xmlns="http://soap.sforce.com/2006/04/metadata">
+    <apiVersion>%s</apiVersion>
+    <isExposed>false</isExposed>
+</LightningComponentBundle>
+`, apiVersion)
+	return
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLightningComponentBundleFiles(t *testing.T) {
+	files := lightningComponentBundleFiles("myComponent", "45.0")
+
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 files got %d", len(files))
+	}
+	if !strings.Contains(files["myComponent.js"], "export default class MyComponent extends LightningElement") {
+		t.Errorf("Unexpected js: %s", files["myComponent.js"])
+	}
+	if !strings.HasPrefix(files["myComponent.html"], "<template>") {
+		t.Errorf("Unexpected html: %s", files["myComponent.html"])
+	}
+	if !strings.Contains(files["myComponent.js-meta.xml"], "<apiVersion>45.0</apiVersion>") {
+		t.Errorf("Unexpected meta: %s", files["myComponent.js-meta.xml"])
+	}
+}
+
+func TestLwcNamePattern(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		{"myComponent", true},
+		{"my_component2", true},
+		{"MyComponent", false},
+		{"my__component", false},
+		{"my-component", false},
+		{"myComponent_", false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.input, func(t *testing.T) {
+			got := lwcNamePattern.MatchString(test.input)
+
+			if got != test.expected {
+				t.Errorf("Expected %v got %v for %s", test.expected, got, test.input)
+			}
+		})
+	}
+}
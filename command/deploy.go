@@ -0,0 +1,190 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdDeploy = &Command{
+	Run:   runDeploy,
+	Usage: "deploy <command> [<args>]",
+	Short: "Check, report on, or cancel a deploy",
+	Long: `
+Check, report on, or cancel a deploy
+
+Deploys started with "force push -async" or "force import -async" can be
+followed up with these commands.
+
+Usage:
+
+  force deploy list [-limit <count>]
+
+  force deploy status <deploy id>
+
+  force deploy report [-wait] <deploy id>
+
+  force deploy cancel <deploy id>
+
+List Options
+  -limit   Number of recent deploys to list (default 20)
+
+Report Options
+  -wait    Wait for the deploy to finish before reporting
+
+Deploys listed with QUICK DEPLOY set to yes are validations that can still be
+deployed with "force quickdeploy".
+
+Examples:
+
+  force deploy list
+
+  force deploy status 0Af1200000FFbBzCAL
+
+  force deploy report -wait 0Af1200000FFbBzCAL
+
+  force deploy cancel 0Af1200000FFbBzCAL
+`,
+	MaxExpectedArgs: -1,
+}
+
+var (
+	waitForDeploy   bool
+	deployListLimit int
+)
+
+func init() {
+	cmdDeploy.Flag.BoolVar(&waitForDeploy, "wait", false, "wait for deploy to finish")
+	cmdDeploy.Flag.IntVar(&deployListLimit, "limit", 20, "number of deploys to list")
+}
+
+func runDeploy(cmd *Command, args []string) {
+	if len(args) == 0 {
+		cmd.PrintUsage()
+		return
+	}
+	subcommand := args[0]
+	args = parseSubcommandFlags(cmd, args[1:])
+	switch subcommand {
+	case "list":
+		runDeployList(args)
+	case "status":
+		runDeployStatus(args)
+	case "report":
+		runDeployReport(args)
+	case "cancel":
+		runDeployCancel(args)
+	default:
+		ErrorAndExit("no such command: %s", subcommand)
+	}
+}
+
+func deployIdFromArgs(args []string) string {
+	if len(args) != 1 {
+		ErrorAndExit("must specify a single deploy id")
+	}
+	return args[0]
+}
+
+func runDeployList(args []string) {
+	if len(args) > 0 {
+		ErrorAndExit("Unrecognized argument: " + args[0])
+	}
+	force, _ := ActiveForce()
+	requests, err := force.ListDeployRequests(deployListLimit)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	quickDeployable := QuickDeployableIds(requests, time.Now())
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tCHECK ONLY\tTEST LEVEL\tSTARTED\tUSER\tQUICK DEPLOY")
+	for _, r := range requests {
+		started := ""
+		if !r.StartDate.IsZero() {
+			started = r.StartDate.Local().Format("2006-01-02 15:04:05")
+		}
+		testLevel := r.TestLevel
+		if testLevel == "" && r.RunTestsEnabled {
+			testLevel = "tests run"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\t%s\n", r.Id, r.Status, r.CheckOnly, testLevel, started, r.CreatedBy, yesNo(quickDeployable[r.Id]))
+	}
+	w.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func runDeployStatus(args []string) {
+	id := deployIdFromArgs(args)
+	force, _ := ActiveForce()
+	result, err := force.Metadata.CheckDeployStatus(id)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	displayDeployStatus(result)
+}
+
+func displayDeployStatus(result ForceCheckDeploymentStatusResult) {
+	fmt.Printf("Deploy Id:  %s\n", result.Id)
+	fmt.Printf("Status:     %s\n", result.Status)
+	if result.StateDetail != "" {
+		fmt.Printf("Detail:     %s\n", result.StateDetail)
+	}
+	fmt.Printf("Check Only: %t\n", result.CheckOnly)
+	fmt.Printf("Components: %d/%d deployed, %d errors\n", result.NumberComponentsDeployed, result.NumberComponentsTotal, result.NumberComponentErrors)
+	fmt.Printf("Tests:      %d/%d completed, %d errors\n", result.NumberTestsCompleted, result.NumberTestsTotal, result.NumberTestErrors)
+	if result.Done {
+		fmt.Printf("Completed:  %s\n", result.CompletedDate)
+	}
+	if result.ErrorMessage != "" {
+		fmt.Printf("Error:      %s %s\n", result.ErrorStatusCode, result.ErrorMessage)
+	}
+}
+
+func runDeployReport(args []string) {
+	id := deployIdFromArgs(args)
+	force, _ := ActiveForce()
+	var result ForceCheckDeploymentStatusResult
+	var err error
+	if waitForDeploy {
+		result, err = force.Metadata.WaitForDeploy(id)
+	} else {
+		result, err = force.Metadata.CheckDeployStatus(id)
+	}
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	if !result.Done {
+		displayDeployStatus(result)
+		ErrorAndExit("Deploy has not finished.  Use -wait to wait for it to finish.")
+	}
+	byName := false
+	namePaths := make(map[string]string)
+	if err = ProcessDeployResults(result, byName, namePaths, nil); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	fmt.Printf("Deploy Id %s\n", result.Id)
+}
+
+func runDeployCancel(args []string) {
+	id := deployIdFromArgs(args)
+	force, _ := ActiveForce()
+	done, err := force.Metadata.CancelDeploy(id)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	if done {
+		fmt.Printf("Deploy %s canceled\n", id)
+	} else {
+		fmt.Printf("Cancellation of deploy %s requested\n", id)
+	}
+}
@@ -0,0 +1,131 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ForceCLI/force/config"
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdDiff = &Command{
+	Run:   runDiff,
+	Usage: "diff [-f <path>]...",
+	Short: "Compare local metadata with the org",
+	Long: `
+Compare local metadata with the metadata in the org
+
+The components are retrieved from the org and a unified diff is displayed for
+each file that differs from the local version.  XML files are normalized as by
+"force format" before being compared, so differences in whitespace and in the
+order of keyed elements like fields and permissions are ignored.  Changes in
+the order of elements like picklist values and layout items are reported.
+
+A summary classifies each component as new, changed, unchanged, or only in
+the org.  If no paths are given, the project's metadata directory is compared,
+and if it has a package.xml, the components listed in it are retrieved so
+components that only exist in the org are reported.
+
+Options
+  -f, -filepath   Path to a file or directory to compare
+
+Examples:
+
+  force diff
+
+  force diff -f src/classes/MyClass.cls -f src/objects/Account.object
+`,
+	MaxExpectedArgs: -1,
+}
+
+var diffPaths metaName
+
+func init() {
+	cmdDiff.Flag.Var(&diffPaths, "f", "Path to resource(s)")
+	cmdDiff.Flag.Var(&diffPaths, "filepath", "Path to resource(s)")
+}
+
+func runDiff(cmd *Command, args []string) {
+	diffPaths = append(diffPaths, args...)
+	packageXml := ""
+	if len(diffPaths) == 0 {
+		root, err := config.GetSourceDir()
+		ExitIfNoSourceDir(err)
+		diffPaths = metaName{root}
+		if _, err := os.Stat(filepath.Join(root, "package.xml")); err == nil {
+			packageXml = filepath.Join(root, "package.xml")
+		}
+	}
+
+	pb := NewPushBuilder()
+	for i, p := range diffPaths {
+		diffPaths[i] = replaceComponentWithBundle(p)
+	}
+	if badPaths := pb.AddPaths(diffPaths, make(map[string]string)); len(badPaths) > 0 {
+		ErrorAndExit("Could not add the following files:\n %v", badPaths)
+	}
+	local, err := pb.ForceMetadataFiles()
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+
+	force, _ := ActiveForce()
+	var org ForceMetadataFiles
+	if packageXml != "" {
+		org, _, err = force.Metadata.RetrieveByPackageXml(packageXml)
+	} else {
+		org, _, err = force.Metadata.Retrieve(packageQuery(pb))
+	}
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+
+	diffs := DiffMetadataFiles(local, org)
+	for _, c := range diffs {
+		for _, f := range c.Files {
+			fmt.Print(f.Diff)
+		}
+	}
+	displayDiffSummary(diffs)
+}
+
+// Build a retrieve request for the components in a package
+func packageQuery(pb PackageBuilder) (query ForceMetadataQuery) {
+	for _, metaType := range pb.Metadata {
+		query = append(query, ForceMetadataQueryElement{
+			Name:    []string{metaType.Name},
+			Members: metaType.Members,
+		})
+	}
+	return
+}
+
+func displayDiffSummary(diffs []ComponentDiff) {
+	for _, status := range []ComponentStatus{ComponentNew, ComponentChanged, ComponentUnchanged, ComponentOnlyInOrg} {
+		var components []ComponentDiff
+		for _, c := range diffs {
+			if c.Status == status {
+				components = append(components, c)
+			}
+		}
+		fmt.Printf("\n%s - %d\n", diffStatusTitle(status), len(components))
+		for _, c := range components {
+			fmt.Printf("\t%s: %s\n", c.Type, c.Name)
+		}
+	}
+}
+
+func diffStatusTitle(status ComponentStatus) string {
+	switch status {
+	case ComponentNew:
+		return "New"
+	case ComponentChanged:
+		return "Changed"
+	case ComponentUnchanged:
+		return "Unchanged"
+	default:
+		return "Only In Org"
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
-	"strings"
 
 	"github.com/ForceCLI/force/config"
 	. "github.com/ForceCLI/force/error"
@@ -20,9 +19,32 @@ var cmdExport = &Command{
 	Long: `
 Export metadata to a local directory
 
+All of the metadata types returned by describeMetadata are exported, including
+standard objects and the contents of folders.
+
 Export Options
   -w, -warnings  # Display warnings about metadata that cannot be retrieved
   -x, -exclude   # Exclude given metadata type
+  -p, -profile   # Select the metadata to export with an export profile
+  -format        # Write metadata XML in the canonical form used by "force format"
+
+An export profile is a JSON file that can be kept with a project so exports
+are reproducible.  All of its keys are optional:
+
+  {
+    "types": ["ApexClass", "CustomObject", "*Settings"],
+    "excludeTypes": ["Document"],
+    "include": ["CustomObject:*__c"],
+    "exclude": ["ApexClass:*_Test"],
+    "standardObjects": ["Account", "Contact"],
+    "namespaces": ["acme"]
+  }
+
+types and excludeTypes select metadata types; they may contain * wildcards.
+include and exclude select components with patterns like those used by
+"force manifest create".  standardObjects selects the standard objects
+exported; all are exported by default.  namespaces adds the components of
+managed packages with those namespaces, which are otherwise not exported.
 
 Examples:
 
@@ -31,6 +53,8 @@ Examples:
   force export org/schema
 
   force export -x ApexClass -x CustomObject
+
+  force export -p export-profile.json
 `,
 	MaxExpectedArgs: 1,
 }
@@ -49,6 +73,8 @@ func (i *metadataList) Set(value string) error {
 var (
 	showWarnings         bool
 	excludeMetadataNames metadataList
+	formatExported       bool
+	exportProfile        string
 )
 
 func init() {
@@ -56,6 +82,9 @@ func init() {
 	cmdExport.Flag.BoolVar(&showWarnings, "warnings", false, "show warnings")
 	cmdExport.Flag.Var(&excludeMetadataNames, "x", "exclude metadata type")
 	cmdExport.Flag.Var(&excludeMetadataNames, "exclude", "exclude metadata type")
+	cmdExport.Flag.StringVar(&exportProfile, "p", "", "export profile")
+	cmdExport.Flag.StringVar(&exportProfile, "profile", "", "export profile")
+	cmdExport.Flag.BoolVar(&formatExported, "format", false, "format metadata XML in the canonical form")
 }
 
 func runExport(cmd *Command, args []string) {
@@ -70,146 +99,29 @@ func runExport(cmd *Command, args []string) {
 		ErrorAndExit(err.Error())
 	}
 	force, _ := ActiveForce()
-	sobjects, err := force.ListSobjects()
-	if err != nil {
-		ErrorAndExit(err.Error())
-	}
-	query := make(ForceMetadataQuery, 0)
-	customObject := "CustomObject"
-
-	sort.Strings(excludeMetadataNames)
 
-	if !isExcluded(customObject) {
-		stdObjects := make([]string, 1, len(sobjects)+1)
-		stdObjects[0] = "*"
-		for _, sobject := range sobjects {
-			name := sobject["name"].(string)
-			if !sobject["custom"].(bool) && !strings.HasSuffix(name, "__Tag") && !strings.HasSuffix(name, "__History") && !strings.HasSuffix(name, "__Share") {
-				stdObjects = append(stdObjects, name)
-			}
+	var profile ExportProfile
+	if exportProfile != "" {
+		if profile, err = LoadExportProfile(exportProfile); err != nil {
+			ErrorAndExit(err.Error())
 		}
-		stdObjects = append(stdObjects, "Activity")
-
-		query = append(query, ForceMetadataQueryElement{Name: []string{customObject}, Members: stdObjects})
 	}
+	sort.Strings(excludeMetadataNames)
 
-	metadataNames := []string{"AccountSettings",
-		"ActivitiesSettings",
-		"AddressSettings",
-		"AnalyticSnapshot",
-		"ApexClass",
-		"ApexComponent",
-		"ApexPage",
-		"ApexTrigger",
-		"ApprovalProcess",
-		"AssignmentRules",
-		"Audience",
-		"AuraDefinitionBundle",
-		"AuthProvider",
-		"AutoResponseRules",
-		"BusinessHoursSettings",
-		"BusinessProcess",
-		"CallCenter",
-		"CaseSettings",
-		"ChatterAnswersSettings",
-		"CompanySettings",
-		"Community",
-		"CompactLayout",
-		"ConnectedApp",
-		"ContentAsset",
-		"ContractSettings",
-		"CustomApplication",
-		"CustomApplicationComponent",
-		"CustomField",
-		"CustomLabels",
-		"CustomMetadata",
-		"CustomObjectTranslation",
-		"CustomPageWebLink",
-		"CustomPermission",
-		"CustomSite",
-		"CustomTab",
-		"DataCategoryGroup",
-		"DuplicateRule",
-		"EntitlementProcess",
-		"EntitlementSettings",
-		"EntitlementTemplate",
-		"ExternalDataSource",
-		"FieldSet",
-		"FlexiPage",
-		"Flow",
-		"FlowDefinition",
-		"Folder",
-		"ForecastingSettings",
-		"GlobalValueSet",
-		"Group",
-		"HomePageComponent",
-		"HomePageLayout",
-		"IdeasSettings",
-		"KnowledgeSettings",
-		"Layout",
-		"Letterhead",
-		"ListView",
-		"LiveAgentSettings",
-		"LiveChatAgentConfig",
-		"LiveChatButton",
-		"LiveChatDeployment",
-		"MatchingRules",
-		"MilestoneType",
-		"MobileSettings",
-		"NamedFilter",
-		"Network",
-		"OpportunitySettings",
-		"PermissionSet",
-		"Portal",
-		"PostTemplate",
-		"ProductSettings",
-		"Profile",
-		"ProfileSessionSetting",
-		"Queue",
-		"QuickAction",
-		"QuoteSettings",
-		"RecordType",
-		"RemoteSiteSetting",
-		"ReportType",
-		"Role",
-		"SamlSsoConfig",
-		"Scontrol",
-		"SecuritySettings",
-		"SharingReason",
-		"SharingRules",
-		"Skill",
-		"StaticResource",
-		"Territory",
-		"Translations",
-		"ValidationRule",
-		"Workflow",
+	describedTypes, err := force.Metadata.MetadataTypeNames()
+	if err != nil {
+		ErrorAndExit("Could not describe metadata types: %s", err.Error())
 	}
-
-	for _, name := range metadataNames {
-		if !isExcluded(name) {
-			query = append(query, ForceMetadataQueryElement{Name: []string{name}, Members: []string{"*"}})
+	var types []string
+	for _, name := range describedTypes {
+		if !isExcluded(name) && profile.IncludesType(name) {
+			types = append(types, name)
 		}
 	}
-
-	folders, err := force.GetAllFolders()
+	query, err := force.ExportQuery(profile, types)
 	if err != nil {
-		err = fmt.Errorf("Could not get folders: %s", err.Error())
 		ErrorAndExit(err.Error())
 	}
-	for foldersType, foldersName := range folders {
-		if foldersType == "Email" {
-			foldersType = "EmailTemplate"
-		}
-		members, err := force.GetMetadataInFolders(foldersType, foldersName)
-		if err != nil {
-			err = fmt.Errorf("Could not get metadata in folders: %s", err.Error())
-			ErrorAndExit(err.Error())
-		}
-
-		if !isExcluded(string(foldersType)) {
-			query = append(query, ForceMetadataQueryElement{Name: []string{string(foldersType)}, Members: members})
-		}
-	}
 
 	if root == "" {
 		root, err = config.GetSourceDir()
@@ -219,7 +131,19 @@ func runExport(cmd *Command, args []string) {
 		}
 	}
 
-	files, problems, err := force.Metadata.Retrieve(query)
+	// Large orgs may need several retrieves, so the package.xml files from
+	// each are merged
+	var packages [][]byte
+	problems, err := force.Metadata.RetrieveSplitToDirectory(query, root, func(name string, data []byte) ([]byte, error) {
+		if name == "package.xml" {
+			packages = append(packages, data)
+			return nil, nil
+		}
+		if formatExported && IsMetadataXmlFile(name) {
+			return FormatMetadataXml(data)
+		}
+		return data, nil
+	})
 	if err != nil {
 		fmt.Printf("Encountered and error with retrieve...\n")
 		ErrorAndExit(err.Error())
@@ -229,15 +153,12 @@ func runExport(cmd *Command, args []string) {
 			fmt.Fprintln(os.Stderr, problem)
 		}
 	}
-	for name, data := range files {
-		file := filepath.Join(root, name)
-		dir := filepath.Dir(file)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			ErrorAndExit(err.Error())
-		}
-		if err := ioutil.WriteFile(filepath.Join(root, name), data, 0644); err != nil {
-			ErrorAndExit(err.Error())
-		}
+	packageXml, err := MergePackageXml(packages...)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "package.xml"), packageXml, 0644); err != nil {
+		ErrorAndExit(err.Error())
 	}
 	fmt.Printf("Exported to %s\n", root)
 }
@@ -4,6 +4,8 @@ import (
 	"testing"
 )
 
+var PushWatchedFiles = pushWatchedFiles
+
 func TestIsExcluded(t *testing.T) {
 	excluded := []string{"ApexClass", "CustomThing"}
 	excludeMetadataNames = append(excludeMetadataNames, excluded...)
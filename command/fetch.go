@@ -27,15 +27,32 @@ var cmdFetch = &Command{
   -u, -unpack     # unpack any zipped static resources (ignored if type is not StaticResource)
   -p, -preserve   # preserve the zip file
   -x, -xml        # provide a package.xml file to fetch data specified within
+  -format         # write metadata XML in the canonical form used by "force format"
+  -changed        # only fetch the components changed since the last fetch -changed
+  -delete         # with -changed, remove the files of components deleted from the org
 
 Export specified artifact(s) to a local directory. Use "package" type to retrieve an unmanaged package.
 
+With -changed, the components of each type are listed in the org and only the
+components that are new, or whose lastModifiedDate or fileName has changed,
+since the last "fetch -changed" into the directory are retrieved.  The
+versions fetched are saved in .force-fetch.json in the directory.  The types
+default to those fetched previously.  Components that no longer exist in the
+org are reported, or removed locally with -delete.  -delete is not supported
+in source format projects.
+
+If the directory is part of a Salesforce DX project (it or a parent directory
+contains sfdx-project.json), the metadata is written in the source format.
+
 Examples
 
   force fetch -t=CustomObject n=Book__c n=Author__c
   force fetch -t Aura -n MyComponent -d /Users/me/Documents/Project/home
   force fetch -t AuraDefinitionBundle -t ApexClass
+  force fetch -t LightningComponentBundle -n myComponent
   force fetch -x myproj/metadata/package.xml
+  force fetch -changed -t ApexClass -t CustomObject
+  force fetch -changed -delete
 `,
 	MaxExpectedArgs: 0,
 }
@@ -64,6 +81,9 @@ var (
 	preserveZip     bool
 	mdbase          string
 	packageXml      string
+	formatFetched   bool
+	fetchChanged    bool
+	deleteRemoved   bool
 )
 
 func init() {
@@ -79,6 +99,9 @@ func init() {
 	cmdFetch.Flag.BoolVar(&preserveZip, "preserve", false, "keep zip file on disk")
 	cmdFetch.Flag.StringVar(&packageXml, "x", "", "Package.xml file to use for fetch.")
 	cmdFetch.Flag.StringVar(&packageXml, "xml", "", "Package.xml file to use for fetch.")
+	cmdFetch.Flag.BoolVar(&formatFetched, "format", false, "Format metadata XML in the canonical form.")
+	cmdFetch.Flag.BoolVar(&fetchChanged, "changed", false, "Only fetch components changed since the last fetch.")
+	cmdFetch.Flag.BoolVar(&deleteRemoved, "delete", false, "Remove files of components deleted from the org.")
 	cmdFetch.Run = runFetch
 	makefile = true
 }
@@ -233,6 +256,10 @@ func runFetch(cmd *Command, args []string) {
 
 	force, _ := ActiveForce()
 
+	if fetchChanged {
+		runFetchChanged(force)
+		return
+	}
 	if len(packageXml) == 0 && len(metadataTypes) == 0 {
 		ErrorAndExit("must specify object type and/or object name or package xml path")
 	}
@@ -243,7 +270,12 @@ func runFetch(cmd *Command, args []string) {
 	var files ForceMetadataFiles
 	var problems []string
 	var err error
-	var expandResources bool = unpack
+
+	for i, metadataType := range metadataTypes {
+		if strings.ToLower(metadataType) == "lwc" {
+			metadataTypes[i] = "LightningComponentBundle"
+		}
+	}
 
 	if len(metadataTypes) == 1 && strings.ToLower(metadataTypes[0]) == "aura" {
 		if len(metadataName) > 0 {
@@ -292,17 +324,30 @@ func runFetch(cmd *Command, args []string) {
 		}
 	}
 
-	var resourcesMap map[string]string
-	resourcesMap = make(map[string]string)
+	writeFetchedFiles(fetchRoot(), files, problems)
+}
 
+// Get the directory to write fetched files to
+func fetchRoot() string {
 	root := targetDirectory
 	if root == "" {
-		root, err = config.GetSourceDir()
-	}
-	if err != nil {
-		fmt.Printf("Error obtaining root directory\n")
-		ErrorAndExit(err.Error())
+		var err error
+		if root, err = config.GetSourceDir(); err != nil {
+			fmt.Printf("Error obtaining root directory\n")
+			ErrorAndExit(err.Error())
+		}
 	}
+	return root
+}
+
+// Write retrieved files to the fetch directory, converting them to the
+// source format and unpacking static resources if requested
+func writeFetchedFiles(root string, files ForceMetadataFiles, problems []string) {
+	var err error
+	var expandResources bool = unpack
+	var resourcesMap map[string]string
+	resourcesMap = make(map[string]string)
+
 	existingPackage, _ := pathExists(filepath.Join(root, "package.xml"))
 
 	for _, problem := range problems {
@@ -311,6 +356,18 @@ func runFetch(cmd *Command, args []string) {
 	if len(files) == 1 {
 		ErrorAndExit("Could not find any objects for " + strings.Join(metadataTypes, ", ") + ". (Is the metadata type correct?)")
 	}
+	if IsSourceFormat(root) {
+		files, err = ConvertToSourceFormat(files)
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+	}
+	if formatFetched {
+		files, err = FormatMetadataFiles(files)
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+	}
 	for name, data := range files {
 		if !existingPackage || name != "package.xml" {
 			file := filepath.Join(root, name)
@@ -417,3 +474,70 @@ func pathExists(path string) (bool, error) {
 	}
 	return false, err
 }
+
+// Fetch the components changed in the org since the last incremental fetch
+func runFetchChanged(force *Force) {
+	root := fetchRoot()
+	if deleteRemoved && IsSourceFormat(root) {
+		ErrorAndExit("-delete is not supported in source format projects")
+	}
+	state, err := LoadFetchState(root)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	var types []string
+	for _, metadataType := range metadataTypes {
+		if strings.ToLower(metadataType) == "lwc" {
+			metadataType = "LightningComponentBundle"
+		}
+		types = append(types, metadataType)
+	}
+	if len(types) == 0 {
+		types = state.Types()
+	}
+	if len(types) == 0 {
+		ErrorAndExit("must specify the metadata types to fetch")
+	}
+
+	current, err := force.ListComponentVersions(types)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	changed, removed := state.Changes(current, types)
+	for _, c := range removed {
+		if !deleteRemoved {
+			fmt.Printf("Deleted from org: %s %s\n", c.Type, c.FullName)
+			continue
+		}
+		paths, err := RemoveComponentFiles(root, c)
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+		for _, p := range paths {
+			fmt.Printf("Removed %s\n", p)
+		}
+	}
+	if len(changed) == 0 {
+		fmt.Println("No changed components to fetch")
+	} else {
+		for _, c := range changed {
+			fmt.Printf("Fetching %s %s\n", c.Type, c.FullName)
+		}
+		files, problems, err := force.Metadata.Retrieve(ComponentsQuery(changed))
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+		metadataTypes = types
+		writeFetchedFiles(root, files, problems)
+		if len(problems) > 0 {
+			// Problems can't be reliably matched to components, so fetch
+			// all the changed components again next time
+			current.Revert(state, changed)
+		}
+	}
+
+	state.Update(current, types)
+	if err = state.Save(root); err != nil {
+		ErrorAndExit(err.Error())
+	}
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ForceCLI/force/config"
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdFormat = &Command{
+	Run:   runFormat,
+	Usage: "format [<path>]...",
+	Short: "Format metadata XML in a canonical form",
+	Long: `
+Format metadata XML files in a canonical form so they can be compared and
+merged easily
+
+Lists of elements identified by a key, such as the fieldPermissions and
+objectPermissions in profiles and permission sets and the fields in objects,
+are sorted by their key, and elements are indented consistently.
+
+Paths may be files or directories.  If no paths are given, the project's
+metadata directory is formatted.  Files that don't contain metadata XML, such
+as Apex classes and static resources, are skipped.
+
+Examples:
+
+  force format
+
+  force format src/profiles src/objects/Account.object
+`,
+	MaxExpectedArgs: -1,
+}
+
+func runFormat(cmd *Command, args []string) {
+	if len(args) == 0 {
+		root, err := config.GetSourceDir()
+		ExitIfNoSourceDir(err)
+		args = []string{root}
+	}
+	formatted := 0
+	for _, root := range args {
+		err := filepath.Walk(root, func(path string, f os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if path != root && strings.HasPrefix(f.Name(), ".") {
+				if f.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if f.IsDir() || !IsMetadataXmlFile(path) {
+				return nil
+			}
+			changed, err := formatFile(path)
+			if changed {
+				formatted++
+			}
+			return err
+		})
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+	}
+	fmt.Printf("Formatted %d files\n", formatted)
+}
+
+// Format a metadata XML file, rewriting it if its formatting changed
+func formatFile(path string) (changed bool, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	formatted, err := FormatMetadataXml(data)
+	if err != nil {
+		err = fmt.Errorf("Could not format %s: %s", path, err.Error())
+		return
+	}
+	if bytes.Equal(data, formatted) {
+		return
+	}
+	changed = true
+	err = ioutil.WriteFile(path, formatted, 0644)
+	return
+}
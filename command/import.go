@@ -27,10 +27,29 @@ Deployment Options
   -allowmissingfiles, -m  Specifies whether a deploy succeeds even if files missing
   -autoupdatepackage, -u  Auto add files to the package if missing
   -test                   Run tests in class (implies -l RunSpecifiedTests)
+  -suite                  Run the classes in test suites (implies -l RunSpecifiedTests)
   -testlevel, -l          Set test level (NoTestRun, RunSpecifiedTests, RunLocalTests, RunAllTestsInOrg)
   -ignorewarnings, -i     Indicates if warnings should fail deployment or not
   -directory, -d 		  Path to the package.xml file to import
   -verbose, -v 			  Provide detailed feedback on operation
+  -async                  Start the deploy and print its id without waiting for it to finish
+  -report                 Write test and deploy results, e.g. junit=results.xml,json=results.json
+  -changed-only           Only deploy files that have changed since they were last deployed
+                          to the org.  Destructive changes are always deployed.
+  -snapshot               Save the org's versions of the components before deploying so
+                          the deploy can be undone with "force rollback".  Wildcard (*)
+                          members are expanded from the files being deployed.
+  -to                     Deploy to each of a comma-separated list of logins instead of
+                          the active login
+  -workers                Number of orgs to deploy to at once with -to (default 4)
+  -stop-on-failure        With -to, skip the orgs not yet deployed to when a deploy fails
+  -split                  Deploy in waves: objects and fields, code, other metadata, and
+                          then layouts and profiles.  Use for deploys over the Metadata
+                          API limits of 39 MB and 10,000 files.  The test level and tests
+                          apply only to the code wave, or the last wave if there's no
+                          code; other waves run no tests.  Production orgs require tests
+                          for every deploy that includes Apex, so a code wave too large
+                          to deploy in one part can only be split in a sandbox.
 
 Examples:
 
@@ -39,12 +58,25 @@ Examples:
   force import -directory=my_metadata -c -r -v
 
   force import -checkonly -runalltests
+
+  force import -checkonly -async
+
+  force import -checkonly -suite Smoke
+
+  force import -split
+
+  force import -changed-only
+
+  force import -snapshot
+
+  force import -to uat,staging -workers 2 -stop-on-failure
 `,
 	MaxExpectedArgs: -1,
 }
 
 var (
 	testsToRun            metaName
+	deployLogins          metaName
 	rollBackOnErrorFlag   = cmdImport.Flag.Bool("rollbackonerror", false, "set roll back on error")
 	runAllTestsFlag       = cmdImport.Flag.Bool("runalltests", false, "set run all tests")
 	testLevelFlag         = cmdImport.Flag.String("testLevel", "NoTestRun", "set test level")
@@ -55,6 +87,13 @@ var (
 	ignoreWarningsFlag    = cmdImport.Flag.Bool("ignorewarnings", false, "set ignore warnings")
 	directory             = cmdImport.Flag.String("directory", "metadata", "relative path to package.xml")
 	verbose               = cmdImport.Flag.Bool("verbose", false, "give more verbose output")
+	asyncDeployFlag       = cmdImport.Flag.Bool("async", false, "start deploy without waiting for it to finish")
+	reportFlag            = cmdImport.Flag.String("report", "", "write results as junit=path.xml,json=path.json")
+	splitDeployFlag       = cmdImport.Flag.Bool("split", false, "deploy in dependency-ordered waves")
+	changedOnlyFlag       = cmdImport.Flag.Bool("changed-only", false, "only deploy files changed since the last deploy")
+	snapshotFlag          = cmdImport.Flag.Bool("snapshot", false, "save a snapshot of the components before deploying")
+	deployWorkersFlag     = cmdImport.Flag.Int("workers", 4, "number of orgs to deploy to at once")
+	stopOnFailureFlag     = cmdImport.Flag.Bool("stop-on-failure", false, "stop deploying to orgs after a failure")
 )
 
 func init() {
@@ -70,6 +109,87 @@ func init() {
 	cmdImport.Flag.BoolVar(ignoreWarningsFlag, "i", false, "set ignore warnings")
 	cmdImport.Flag.StringVar(directory, "d", "metadata", "relative path to package.xml")
 	cmdImport.Flag.Var(&testsToRun, "test", "Test(s) to run")
+	cmdImport.Flag.Var(&testSuites, "suite", "test suites to run")
+	cmdImport.Flag.Var(&deployLogins, "to", "logins to deploy to")
+}
+
+func validateReportSpec(spec string) {
+	if spec == "" {
+		return
+	}
+	if _, err := ParseReportSpec(spec); err != nil {
+		ErrorAndExit(err.Error())
+	}
+}
+
+// Get the tests to run in a deploy: the -test classes and the classes in the
+// -suite test suites
+func deployTests() (tests []string) {
+	if len(testSuites) == 0 {
+		return testsToRun
+	}
+	force, _ := ActiveForce()
+	tests, err := addTestSuiteClasses(testsToRun, testSuites, force.TestSuiteClasses)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	return
+}
+
+// Add the classes in test suites to a list of tests, skipping classes
+// already in the list
+func addTestSuiteClasses(tests []string, suites []string, suiteClasses func([]string) ([]string, error)) (merged []string, err error) {
+	classes, err := suiteClasses(suites)
+	if err != nil {
+		return
+	}
+	if len(classes) == 0 {
+		err = fmt.Errorf("No test classes in test suites: %s", strings.Join(suites, ", "))
+		return
+	}
+	merged = append(merged, tests...)
+	seen := make(map[string]bool)
+	for _, test := range tests {
+		seen[strings.ToLower(test)] = true
+	}
+	for _, class := range classes {
+		if !seen[strings.ToLower(class)] {
+			merged = append(merged, class)
+		}
+	}
+	return
+}
+
+// Waves of a split deploy are deployed one after another, so they can't be
+// started asynchronously or reported on together
+func validateSplitDeploy(opts *ForceDeployOptions) {
+	if !opts.Split {
+		return
+	}
+	if opts.Async {
+		ErrorAndExit("-split cannot be used with -async")
+	}
+	if opts.Report != "" {
+		ErrorAndExit("-split cannot be used with -report")
+	}
+}
+
+// Deploys to several orgs run concurrently, so only options that apply to
+// each deploy independently can be used
+func validateMultiOrgDeploy(opts *ForceDeployOptions) {
+	if len(opts.To) == 0 {
+		return
+	}
+	switch {
+	case opts.Async:
+		ErrorAndExit("-async cannot be used with -to")
+	case opts.Report != "":
+		ErrorAndExit("-report cannot be used with -to")
+	case opts.Snapshot:
+		ErrorAndExit("-snapshot cannot be used with -to")
+	case opts.Split:
+		ErrorAndExit("-split cannot be used with -to")
+	}
 }
 
 func runImport(cmd *Command, args []string) {
@@ -124,6 +244,15 @@ func runImport(cmd *Command, args []string) {
 	if err != nil {
 		ErrorAndExit(err.Error())
 	}
+	if *changedOnlyFlag {
+		if files, err = changedImportFiles(force, root, files); err != nil {
+			ErrorAndExit(err.Error())
+		}
+		if files == nil {
+			fmt.Println("No changes to import")
+			return
+		}
+	}
 	var DeploymentOptions ForceDeployOptions
 	DeploymentOptions.AllowMissingFiles = *allowMissingFilesFlag
 	DeploymentOptions.AutoUpdatePackage = *autoUpdatePackageFlag
@@ -135,7 +264,45 @@ func runImport(cmd *Command, args []string) {
 	if *runAllTestsFlag {
 		DeploymentOptions.TestLevel = "RunAllTestsInOrg"
 	}
-	DeploymentOptions.RunTests = testsToRun
+	DeploymentOptions.RunTests = deployTests()
+	DeploymentOptions.Report = *reportFlag
+	DeploymentOptions.Async = *asyncDeployFlag
+	DeploymentOptions.Split = *splitDeployFlag
+	DeploymentOptions.Snapshot = *snapshotFlag
+	DeploymentOptions.To = deployLogins
+	DeploymentOptions.Workers = *deployWorkersFlag
+	DeploymentOptions.StopOnFailure = *stopOnFailureFlag
+	validateReportSpec(DeploymentOptions.Report)
+	validateSplitDeploy(&DeploymentOptions)
+	validateMultiOrgDeploy(&DeploymentOptions)
+
+	if err := SnapshotBeforeDeploy(files, &DeploymentOptions); err != nil {
+		ErrorAndExit(err.Error())
+	}
+
+	if len(DeploymentOptions.To) > 0 {
+		results, err := DeployToOrgs(DeploymentOptions.To, files, DeploymentOptions, DeploymentOptions.Workers, DeploymentOptions.StopOnFailure)
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+		if err = DisplayOrgDeployResults(results); err != nil {
+			ErrorAndExit(err.Error())
+		}
+		fmt.Printf("Imported from %s\n", root)
+		return
+	}
+	if DeploymentOptions.Split {
+		if err := DeployInWaves(files, false, make(map[string]string), &DeploymentOptions); err != nil {
+			ErrorAndExit(err.Error())
+		}
+		fmt.Printf("Imported from %s\n", root)
+		return
+	}
+	if *asyncDeployFlag {
+		id, err := force.Metadata.StartDeploy(files, DeploymentOptions)
+		DisplayDeployStarted(id, err)
+		return
+	}
 
 	result, err := force.Metadata.Deploy(files, DeploymentOptions)
 	problems := result.Details.ComponentFailures
@@ -146,6 +313,11 @@ func runImport(cmd *Command, args []string) {
 	if err != nil {
 		ErrorAndExit(err.Error())
 	}
+	if DeploymentOptions.Report != "" {
+		if err := NewDeployReport(result).Write(DeploymentOptions.Report); err != nil {
+			ErrorAndExit("Could not write report: %s", err.Error())
+		}
+	}
 
 	fmt.Printf("\nSuccesses - %d\n", len(successes))
 	if *verbose {
@@ -204,3 +376,55 @@ func runImport(cmd *Command, args []string) {
 	}
 	fmt.Printf("Imported from %s\n", root)
 }
+
+// Build a package of the files that have changed since they were last
+// deployed to the org.  Whole bundles are deployed when any of their files
+// change.  nil is returned if nothing has changed.
+func changedImportFiles(force *Force, root string, files ForceMetadataFiles) (changedFiles ForceMetadataFiles, err error) {
+	hashes, err := force.Metadata.DeployHashes()
+	if err != nil {
+		return
+	}
+	var changed []string
+	for _, name := range hashes.Changed(files) {
+		fmt.Printf("Changed: %s\n", name)
+		path := filepath.Join(root, name)
+		// Meta files are deployed with their component
+		if source := strings.TrimSuffix(path, "-meta.xml"); source != path && pathExistsOnDisk(source) {
+			path = source
+		}
+		changed = append(changed, path)
+	}
+	manifests := destructiveManifests(files)
+	if len(changed) == 0 && len(manifests) == 0 {
+		return
+	}
+	pb := NewPushBuilder()
+	if len(changed) > 0 {
+		delta := ComputeDeltaChanges(root, changed, nil)
+		if badPaths := pb.AddPaths(delta.Paths, make(map[string]string)); len(badPaths) > 0 {
+			err = fmt.Errorf("Could not add the following files:\n %s", strings.Join(badPaths, "\n"))
+			return
+		}
+	}
+	changedFiles, err = pb.ForceMetadataFiles()
+	if err != nil {
+		return
+	}
+	// Destructive changes aren't hashed, so they're always deployed
+	for name, data := range manifests {
+		changedFiles[name] = data
+	}
+	return
+}
+
+// Get the destructiveChanges*.xml manifests in a package
+func destructiveManifests(files ForceMetadataFiles) (manifests ForceMetadataFiles) {
+	manifests = make(ForceMetadataFiles)
+	for name, data := range files {
+		if filepath.Dir(filepath.FromSlash(name)) == "." && strings.HasPrefix(name, "destructiveChanges") && strings.HasSuffix(name, ".xml") {
+			manifests[name] = data
+		}
+	}
+	return
+}
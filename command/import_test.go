@@ -0,0 +1,58 @@
+package command
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	. "github.com/ForceCLI/force/lib"
+)
+
+func TestDestructiveManifests(t *testing.T) {
+	files := ForceMetadataFiles{
+		"package.xml":                      []byte("<Package/>"),
+		"destructiveChanges.xml":           []byte("<Package>pre</Package>"),
+		"destructiveChangesPost.xml":       []byte("<Package>post</Package>"),
+		"classes/Changed.cls":              []byte("class Changed {}"),
+		"documents/destructiveChanges.xml": []byte("<Document/>"),
+	}
+	expected := ForceMetadataFiles{
+		"destructiveChanges.xml":     files["destructiveChanges.xml"],
+		"destructiveChangesPost.xml": files["destructiveChangesPost.xml"],
+	}
+	if manifests := destructiveManifests(files); !reflect.DeepEqual(manifests, expected) {
+		t.Errorf("expected %v, got %v", expected, manifests)
+	}
+}
+
+func TestAddTestSuiteClasses(t *testing.T) {
+	suiteClasses := func(suites []string) ([]string, error) {
+		classes := map[string][]string{
+			"smoke": {"AccountTest", "ContactTest"},
+			"empty": nil,
+		}
+		var merged []string
+		for _, suite := range suites {
+			c, found := classes[suite]
+			if !found {
+				return nil, fmt.Errorf("Test suite %s not found", suite)
+			}
+			merged = append(merged, c...)
+		}
+		return merged, nil
+	}
+
+	tests, err := addTestSuiteClasses([]string{"accounttest", "OrderTest"}, []string{"smoke"}, suiteClasses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"accounttest", "OrderTest", "ContactTest"}; !reflect.DeepEqual(tests, expected) {
+		t.Errorf("expected %v, got %v", expected, tests)
+	}
+	if _, err := addTestSuiteClasses(nil, []string{"missing"}, suiteClasses); err == nil || err.Error() != "Test suite missing not found" {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if _, err := addTestSuiteClasses(nil, []string{"empty"}, suiteClasses); err == nil {
+		t.Errorf("expected error for suite without classes")
+	}
+}
@@ -0,0 +1,184 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdLead = &Command{
+	Run:   runLead,
+	Usage: "lead convert <leadId> [options]",
+	Short: "Convert leads",
+	Long: `
+Convert leads
+
+Usage:
+
+  force lead convert <leadId> [options]
+
+  force lead convert <file> [options]
+
+A file is a CSV file of lead id, account id pairs.  The account id may be
+left blank to create a new account.
+
+Convert Options
+  -account          Id of the account to merge the lead into
+  -contact          Id of the contact to merge the lead into
+  -status           Converted lead status (default is the first converted status)
+  -owner            Id of the owner of the new records
+  -opportunity      Name of the opportunity to create
+  -noopportunity    Do not create an opportunity
+  -notify           Send a notification email to the owner
+
+Examples:
+
+  force lead convert 00Qi000000000AAA
+
+  force lead convert 00Qi000000000AAA -account 001i000000000AAA -noopportunity
+
+  force lead convert -status "Closed - Converted" leads.csv
+`,
+	MaxExpectedArgs: -1,
+}
+
+var (
+	leadAccountId       string
+	leadContactId       string
+	leadStatus          string
+	leadOwnerId         string
+	leadOpportunityName string
+	leadNoOpportunity   bool
+	leadNotify          bool
+)
+
+func init() {
+	cmdLead.Flag.StringVar(&leadAccountId, "account", "", "account id")
+	cmdLead.Flag.StringVar(&leadContactId, "contact", "", "contact id")
+	cmdLead.Flag.StringVar(&leadStatus, "status", "", "converted lead status")
+	cmdLead.Flag.StringVar(&leadOwnerId, "owner", "", "owner id")
+	cmdLead.Flag.StringVar(&leadOpportunityName, "opportunity", "", "opportunity name")
+	cmdLead.Flag.BoolVar(&leadNoOpportunity, "noopportunity", false, "do not create an opportunity")
+	cmdLead.Flag.BoolVar(&leadNotify, "notify", false, "send notification email")
+}
+
+func runLead(cmd *Command, args []string) {
+	if len(args) == 0 {
+		cmd.PrintUsage()
+		return
+	}
+	switch args[0] {
+	case "convert":
+		runLeadConvert(parseSubcommandFlags(cmd, args[1:]))
+	default:
+		ErrorAndExit("no such command: %s", args[0])
+	}
+}
+
+func runLeadConvert(args []string) {
+	if len(args) != 1 {
+		ErrorAndExit("must specify a lead id or a file")
+	}
+	force, _ := ActiveForce()
+
+	status := leadStatus
+	if status == "" {
+		var err error
+		status, err = defaultConvertedStatus(force)
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+	}
+
+	var converts []LeadConvert
+	if isRecordId(args[0]) {
+		converts = append(converts, newLeadConvert(args[0], leadAccountId, status))
+	} else {
+		pairs, err := readLeadAccountPairs(args[0])
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+		for _, pair := range pairs {
+			accountId := pair[1]
+			if accountId == "" {
+				accountId = leadAccountId
+			}
+			converts = append(converts, newLeadConvert(pair[0], accountId, status))
+		}
+	}
+	if len(converts) == 0 {
+		ErrorAndExit("no leads to convert")
+	}
+
+	results, err := force.Partner.ConvertLead(converts)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	failures := 0
+	for _, result := range results {
+		if result.Success {
+			fmt.Printf("%s: converted\n\taccount: %s\n\tcontact: %s\n", result.LeadId, result.AccountId, result.ContactId)
+			if result.OpportunityId != "" {
+				fmt.Printf("\topportunity: %s\n", result.OpportunityId)
+			}
+		} else {
+			failures++
+			fmt.Printf("%s: FAILED %s\n", result.LeadId, ForcePartnerResult{Errors: result.Errors}.Error())
+		}
+	}
+	fmt.Printf("\n%d succeeded, %d failed\n", len(results)-failures, failures)
+	if failures > 0 {
+		ErrorAndExit("Some leads failed to convert")
+	}
+}
+
+func newLeadConvert(leadId, accountId, status string) LeadConvert {
+	return LeadConvert{
+		LeadId:                 leadId,
+		AccountId:              accountId,
+		ContactId:              leadContactId,
+		ConvertedStatus:        status,
+		OwnerId:                leadOwnerId,
+		OpportunityName:        leadOpportunityName,
+		DoNotCreateOpportunity: leadNoOpportunity,
+		SendNotificationEmail:  leadNotify,
+	}
+}
+
+// Read lead id, account id pairs.  Rows with only a lead id are allowed.
+func readLeadAccountPairs(path string) (pairs [][]string, err error) {
+	rows, err := readCSVRows(path)
+	if err != nil {
+		return
+	}
+	for i, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		leadId := strings.TrimSpace(row[0])
+		if i == 0 && !isRecordId(leadId) {
+			continue
+		}
+		accountId := ""
+		if len(row) > 1 {
+			accountId = strings.TrimSpace(row[1])
+		}
+		pairs = append(pairs, []string{leadId, accountId})
+	}
+	return
+}
+
+func defaultConvertedStatus(force *Force) (status string, err error) {
+	result, err := force.Query("SELECT MasterLabel FROM LeadStatus WHERE IsConverted = true ORDER BY SortOrder LIMIT 1")
+	if err != nil {
+		return
+	}
+	if len(result.Records) == 0 {
+		err = fmt.Errorf("No converted lead status found.  Use -status to specify one.")
+		return
+	}
+	status = result.Records[0]["MasterLabel"].(string)
+	return
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"fmt"
+
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdLwc = &Command{
+	Run:   runLwc,
+	Usage: "lwc <command>",
+	Short: "List Lightning Web Components",
+	Long: `
+List Lightning Web Components
+
+Lightning Web Component bundles are stored in the lwc directory and can be
+created with "force create -t lwc", deployed with "force push", and
+retrieved with "force fetch -t LightningComponentBundle".
+
+Usage:
+
+  force lwc list
+
+Examples:
+
+  force lwc list
+
+  force create -t lwc -n myComponent
+
+  force push -f metadata/lwc/myComponent
+`,
+	MaxExpectedArgs: -1,
+}
+
+func runLwc(cmd *Command, args []string) {
+	if len(args) == 0 {
+		cmd.PrintUsage()
+		return
+	}
+	switch args[0] {
+	case "list":
+		runLwcList()
+	default:
+		ErrorAndExit("no such command: %s", args[0])
+	}
+}
+
+func runLwcList() {
+	force, _ := ActiveForce()
+	bundles, err := force.GetLightningComponentBundles()
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	for _, bundle := range bundles.Records {
+		name := bundle["DeveloperName"].(string)
+		if namespace, ok := bundle["NamespacePrefix"].(string); ok && namespace != "" {
+			name = namespace + "__" + name
+		}
+		fmt.Println(name)
+	}
+}
@@ -0,0 +1,132 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ForceCLI/force/config"
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdManifest = &Command{
+	Run:   runManifest,
+	Usage: "manifest create [-d <directory> | -from-org [-t <metadata type>]] [-o <file>] [-include <pattern>] [-exclude <pattern>]",
+	Short: "Generate a package.xml",
+	Long: `
+Generate a package.xml from the metadata in a local directory or in the org
+
+The components in the package.xml are sorted by metadata type and name.  By
+default, the package.xml is built from the project's metadata directory and
+written to standard output.
+
+Create Options
+  -d, -directory   Build the package.xml from the metadata in a directory
+  -from-org        Build the package.xml from the metadata in the org
+  -t, -type        Metadata type to list from the org (default all types)
+  -o, -output      File to write the package.xml to
+  -include         Only include components matching the pattern
+  -exclude         Exclude components matching the pattern
+
+Patterns are a metadata type, or a metadata type and component name separated
+by a colon, e.g. ApexClass or ApexClass:*_Test.  Both may contain * wildcards.
+The -t, -include, and -exclude options may be repeated or given a
+comma-separated list.
+
+Examples:
+
+  force manifest create -d src -o src/package.xml
+
+  force manifest create -exclude 'ApexClass:*_Test' -exclude Profile
+
+  force manifest create -from-org -t ApexClass,ApexTrigger -o package.xml
+
+  force manifest create -from-org -include 'CustomObject:*__c'
+`,
+	MaxExpectedArgs: -1,
+}
+
+var (
+	manifestDirectory string
+	manifestFromOrg   bool
+	manifestTypes     metaName
+	manifestOutput    string
+	manifestIncludes  metaName
+	manifestExcludes  metaName
+)
+
+func init() {
+	cmdManifest.Flag.StringVar(&manifestDirectory, "d", "", "directory to build package.xml from")
+	cmdManifest.Flag.StringVar(&manifestDirectory, "directory", "", "directory to build package.xml from")
+	cmdManifest.Flag.BoolVar(&manifestFromOrg, "from-org", false, "build package.xml from the org")
+	cmdManifest.Flag.Var(&manifestTypes, "t", "metadata type to list from the org")
+	cmdManifest.Flag.Var(&manifestTypes, "type", "metadata type to list from the org")
+	cmdManifest.Flag.StringVar(&manifestOutput, "o", "", "file to write package.xml to")
+	cmdManifest.Flag.StringVar(&manifestOutput, "output", "", "file to write package.xml to")
+	cmdManifest.Flag.Var(&manifestIncludes, "include", "only include components matching pattern")
+	cmdManifest.Flag.Var(&manifestExcludes, "exclude", "exclude components matching pattern")
+}
+
+func runManifest(cmd *Command, args []string) {
+	if len(args) == 0 {
+		cmd.PrintUsage()
+		return
+	}
+	subcommand := args[0]
+	args = parseSubcommandFlags(cmd, args[1:])
+	switch subcommand {
+	case "create":
+		runManifestCreate(args)
+	default:
+		ErrorAndExit("no such command: %s", subcommand)
+	}
+}
+
+func runManifestCreate(args []string) {
+	if len(args) > 0 {
+		ErrorAndExit("unexpected arguments: %v", args)
+	}
+	if manifestFromOrg && manifestDirectory != "" {
+		ErrorAndExit("The -d and -from-org parameters cannot be combined.")
+	}
+	if !manifestFromOrg && len(manifestTypes) > 0 {
+		ErrorAndExit("The -t parameter can only be used with -from-org.")
+	}
+
+	var pb PackageBuilder
+	var err error
+	if manifestFromOrg {
+		force, _ := ActiveForce()
+		pb, err = force.OrgPackageBuilder(manifestTypes)
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+	} else {
+		pb = directoryPackageBuilder(manifestDirectory)
+	}
+	pb.Filter(ManifestFilter{Include: manifestIncludes, Exclude: manifestExcludes})
+
+	if manifestOutput == "" {
+		fmt.Print(string(pb.PackageXml()))
+		return
+	}
+	if err = ioutil.WriteFile(manifestOutput, pb.PackageXml(), 0644); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	fmt.Printf("Wrote %s\n", manifestOutput)
+}
+
+// Build a package containing the metadata in a directory, defaulting to the
+// project's metadata directory
+func directoryPackageBuilder(dir string) (pb PackageBuilder) {
+	if dir == "" {
+		var err error
+		dir, err = config.GetSourceDir()
+		ExitIfNoSourceDir(err)
+	}
+	pb = NewFetchBuilder()
+	if _, _, err := pb.AddDirectory(dir, AddDirectoryOptions{SkipNonComponents: true}); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	return
+}
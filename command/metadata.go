@@ -0,0 +1,159 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdMetadata = &Command{
+	Run:   runMetadata,
+	Usage: "metadata <command> <type> <full name> [<args>]",
+	Short: "Read, create, update, or delete any metadata component",
+	Long: `
+Read, create, update, or delete any metadata component synchronously, without
+deploying a package
+
+Components are read and written as metadata XML documents, the same as the
+files retrieved by fetch, or as JSON objects whose keys are element names.
+The component to save is read from a file given with -f, or from standard
+input.  Its fullName is set from the command line.
+
+Usage:
+
+  force metadata read <type> <full name> [-json]
+
+  force metadata create <type> <full name> [-f <file>]
+
+  force metadata update <type> <full name> [-f <file>]
+
+  force metadata upsert <type> <full name> [-f <file>]
+
+  force metadata delete <type> <full name>
+
+  force metadata rename <type> <old full name> <new full name>
+
+Examples:
+
+  force metadata read Layout 'Account-Account Layout' > layout.xml
+
+  force metadata upsert Layout 'Account-Account Layout' -f layout.xml
+
+  echo '{"endpoint": "https://example.com", "label": "Shipping", "principalType": "Anonymous", "protocol": "NoAuthentication"}' | \
+    force metadata upsert NamedCredential Shipping
+
+  force metadata rename CustomTab Old_Tab__c New_Tab__c
+
+  force metadata delete RemoteSiteSetting Example
+`,
+	MaxExpectedArgs: -1,
+}
+
+var (
+	metadataFile string
+	metadataJson bool
+)
+
+func init() {
+	cmdMetadata.Flag.StringVar(&metadataFile, "f", "", "file containing the component")
+	cmdMetadata.Flag.StringVar(&metadataFile, "file", "", "file containing the component")
+	cmdMetadata.Flag.BoolVar(&metadataJson, "json", false, "display the component as JSON")
+}
+
+func runMetadata(cmd *Command, args []string) {
+	if len(args) == 0 {
+		cmd.PrintUsage()
+		return
+	}
+	subcommand := args[0]
+	args = parseSubcommandFlags(cmd, args[1:])
+	switch subcommand {
+	case "read":
+		runMetadataRead(args)
+	case "create", "update", "upsert":
+		runMetadataSave(subcommand, args)
+	case "delete":
+		runMetadataDelete(args)
+	case "rename":
+		runMetadataRename(args)
+	default:
+		ErrorAndExit("no such command: %s", subcommand)
+	}
+}
+
+func runMetadataRead(args []string) {
+	if len(args) != 2 {
+		ErrorAndExit("must specify metadata type and full name")
+	}
+	force, _ := ActiveForce()
+	metadata, err := force.Metadata.ReadMetadata(args[0], args[1])
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	if metadataJson {
+		if metadata, err = MetadataXmlToJson(metadata); err != nil {
+			ErrorAndExit(err.Error())
+		}
+		metadata = append(metadata, '\n')
+	}
+	fmt.Print(string(metadata))
+}
+
+func runMetadataSave(action string, args []string) {
+	if len(args) != 2 {
+		ErrorAndExit("must specify metadata type and full name")
+	}
+	var metadata []byte
+	var err error
+	if metadataFile != "" {
+		metadata, err = ioutil.ReadFile(metadataFile)
+	} else {
+		metadata, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+
+	force, _ := ActiveForce()
+	save := force.Metadata.UpsertMetadata
+	switch action {
+	case "create":
+		save = force.Metadata.CreateMetadata
+	case "update":
+		save = force.Metadata.UpdateMetadata
+	}
+	result, err := save(args[0], args[1], metadata)
+	if err != nil {
+		ErrorAndExit("Failed to %s %s %s: %s", action, args[0], args[1], err.Error())
+	}
+	verb := "Updated"
+	if action == "create" || result.Created {
+		verb = "Created"
+	}
+	fmt.Printf("%s %s %s\n", verb, args[0], result.FullName)
+}
+
+func runMetadataDelete(args []string) {
+	if len(args) != 2 {
+		ErrorAndExit("must specify metadata type and full name")
+	}
+	force, _ := ActiveForce()
+	if _, err := force.Metadata.DeleteMetadata(args[0], args[1]); err != nil {
+		ErrorAndExit("Failed to delete %s %s: %s", args[0], args[1], err.Error())
+	}
+	fmt.Printf("Deleted %s %s\n", args[0], args[1])
+}
+
+func runMetadataRename(args []string) {
+	if len(args) != 3 {
+		ErrorAndExit("must specify metadata type, old full name, and new full name")
+	}
+	force, _ := ActiveForce()
+	if _, err := force.Metadata.RenameMetadata(args[0], args[1], args[2]); err != nil {
+		ErrorAndExit("Failed to rename %s %s: %s", args[0], args[1], err.Error())
+	}
+	fmt.Printf("Renamed %s %s to %s\n", args[0], args[1], args[2])
+}
@@ -0,0 +1,423 @@
+package command
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdMirror = &Command{
+	Run:   runMirror,
+	Usage: "mirror sync [-db <file>] <object>...",
+	Short: "Mirror org data into a local SQLite database",
+	Long: `
+Mirror org data into a local SQLite database
+
+The first sync of an object creates a table from the object's describe and
+loads every record.  Later syncs only pull records whose SystemModstamp has
+changed since the previous sync, and remove records that have been deleted
+in the org.  The high-water mark for each object is stored in the database.
+Objects with too many fields to select in one query are queried in groups
+of fields.
+
+Usage:
+
+  force mirror sync [-db <file>] <object>...
+
+Mirror Options
+  -db      Path to SQLite database (default org.sqlite)
+  -full    Reload all records, ignoring the stored high-water mark
+
+Examples:
+
+  force mirror sync Account Contact Opportunity
+
+  force mirror sync -db org.sqlite Account Contact Opportunity
+
+  force mirror sync -full -db org.sqlite Account
+`,
+	MaxExpectedArgs: -1,
+}
+
+// Name of the table used to track the high-water mark of each object
+const mirrorSyncTable = "_force_mirror_sync"
+
+// Layout of datetime values returned by the REST API
+const mirrorDatetimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// The longest encoded SOQL query to send in a query URL.  Salesforce rejects
+// URLs longer than 16,384 characters, so this leaves room for the rest of
+// the URL.
+const mirrorMaxQueryLength = 16000
+
+var (
+	mirrorDatabase string
+	mirrorFull     bool
+)
+
+func init() {
+	cmdMirror.Flag.StringVar(&mirrorDatabase, "db", "org.sqlite", "path to SQLite database")
+	cmdMirror.Flag.BoolVar(&mirrorFull, "full", false, "reload all records")
+}
+
+type mirrorField struct {
+	Name string
+	Type string
+}
+
+func runMirror(cmd *Command, args []string) {
+	if len(args) == 0 {
+		cmd.PrintUsage()
+		return
+	}
+	switch args[0] {
+	case "sync":
+		// Allow options to follow the sub-command
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			ErrorAndExit(err.Error())
+		}
+		runMirrorSync(cmd.Flag.Args())
+	default:
+		ErrorAndExit("no such command: %s", args[0])
+	}
+}
+
+func runMirrorSync(objects []string) {
+	if len(objects) == 0 {
+		ErrorAndExit("must specify at least one object to mirror")
+	}
+	force, _ := ActiveForce()
+	db, err := sql.Open("sqlite3", mirrorDatabase)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	defer db.Close()
+
+	if _, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (object TEXT PRIMARY KEY, modstamp TEXT, synced TEXT)`, mirrorSyncTable)); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	for _, object := range objects {
+		if err := mirrorObject(force, db, object); err != nil {
+			ErrorAndExit("Could not mirror %s: %s", object, err.Error())
+		}
+	}
+}
+
+// Sync a single object into its table, creating or extending the table as
+// needed.
+func mirrorObject(force *Force, db *sql.DB, object string) (err error) {
+	sobject, err := force.GetSobject(object)
+	if err != nil {
+		return
+	}
+	fields := mirrorFields(sobject)
+	if len(fields) == 0 {
+		return fmt.Errorf("no queryable fields found")
+	}
+	if err = mirrorTable(db, object, fields); err != nil {
+		return
+	}
+
+	hasModstamp := hasMirrorField(fields, "SystemModstamp")
+	hasIsDeleted := hasMirrorField(fields, "IsDeleted")
+
+	var since string
+	if !mirrorFull && hasModstamp {
+		row := db.QueryRow(fmt.Sprintf(`SELECT modstamp FROM %s WHERE object = ?`, mirrorSyncTable), object)
+		var modstamp sql.NullString
+		if err = row.Scan(&modstamp); err != nil && err != sql.ErrNoRows {
+			return
+		}
+		since = modstamp.String
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var where string
+	if since == "" {
+		if _, err = tx.Exec(fmt.Sprintf(`DELETE FROM %s`, quoteMirrorIdent(object))); err != nil {
+			return
+		}
+	} else {
+		where = fmt.Sprintf(" WHERE SystemModstamp >= %s", since)
+	}
+
+	// Objects with many fields are queried in groups of fields so the query
+	// URL isn't too long.  Each group updates its own columns of the records.
+	highWater := since
+	upserted := 0
+	for g, group := range mirrorFieldGroups(object, fields, where) {
+		names := make([]string, len(group))
+		for i, f := range group {
+			names[i] = f.Name
+		}
+		var upsert *sql.Stmt
+		if upsert, err = tx.Prepare(mirrorUpsertSql(object, names)); err != nil {
+			return
+		}
+		soql := fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(names, ", "), object, where)
+		err = mirrorQuery(force, soql, false, func(record ForceRecord) error {
+			values := make([]interface{}, len(group))
+			for i, f := range group {
+				values[i] = mirrorValue(f, record[f.Name])
+			}
+			if _, err := upsert.Exec(values...); err != nil {
+				return err
+			}
+			if g == 0 {
+				upserted++
+			}
+			if stamp, ok := record["SystemModstamp"].(string); ok {
+				if s := mirrorSoqlDatetime(stamp); s > highWater {
+					highWater = s
+				}
+			}
+			return nil
+		})
+		upsert.Close()
+		if err != nil {
+			return
+		}
+	}
+
+	deleted := 0
+	if since != "" && hasIsDeleted {
+		soql := fmt.Sprintf("SELECT Id, SystemModstamp FROM %s WHERE IsDeleted = true AND SystemModstamp >= %s", object, since)
+		err = mirrorQuery(force, soql, true, func(record ForceRecord) error {
+			if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE "Id" = ?`, quoteMirrorIdent(object)), record["Id"]); err != nil {
+				return err
+			}
+			deleted++
+			if stamp, ok := record["SystemModstamp"].(string); ok {
+				if s := mirrorSoqlDatetime(stamp); s > highWater {
+					highWater = s
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`INSERT OR REPLACE INTO %s (object, modstamp, synced) VALUES (?, ?, ?)`, mirrorSyncTable),
+		object, highWater, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	fmt.Printf("%s: %d records updated, %d records deleted\n", object, upserted, deleted)
+	return
+}
+
+// Run a query, passing each record to fn.  If fn returns an error, the
+// remaining records are drained and the error returned.
+func mirrorQuery(force *Force, soql string, all bool, fn func(ForceRecord) error) (err error) {
+	records := make(chan ForceRecord)
+	queryErr := make(chan error, 1)
+	var options []func(*QueryOptions)
+	if all {
+		options = append(options, func(options *QueryOptions) {
+			options.QueryAll = true
+		})
+	}
+	go func() {
+		queryErr <- force.QueryAndSend(soql, records, options...)
+	}()
+	for record := range records {
+		if err == nil {
+			err = fn(record)
+		}
+	}
+	if qerr := <-queryErr; err == nil {
+		err = qerr
+	}
+	return
+}
+
+// Create the table for an object, or add columns for any fields that have
+// been added since the table was created.
+func mirrorTable(db *sql.DB, object string, fields []mirrorField) (err error) {
+	rows, err := db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, quoteMirrorIdent(object)))
+	if err != nil {
+		return
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid int
+		var name, typ string
+		var notNull, pk int
+		var dflt sql.NullString
+		if err = rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return
+		}
+		existing[strings.ToLower(name)] = true
+	}
+	rows.Close()
+
+	if len(existing) == 0 {
+		_, err = db.Exec(mirrorCreateTableSql(object, fields))
+		return
+	}
+	for _, f := range fields {
+		if existing[strings.ToLower(f.Name)] {
+			continue
+		}
+		_, err = db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, quoteMirrorIdent(object), quoteMirrorIdent(f.Name), mirrorColumnType(f.Type)))
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// mirrorFields returns the fields of an sobject describe that can be stored
+// in a mirror table.  Compound and binary fields are skipped; their
+// components are available as separate fields.
+func mirrorFields(sobject ForceSobject) (fields []mirrorField) {
+	describeFields, _ := sobject["fields"].([]interface{})
+	for _, f := range describeFields {
+		field, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := field["name"].(string)
+		typ, _ := field["type"].(string)
+		switch typ {
+		case "address", "location", "base64":
+			continue
+		}
+		fields = append(fields, mirrorField{Name: name, Type: typ})
+	}
+	return
+}
+
+// mirrorColumnType maps a Salesforce field type to a SQLite column type.
+func mirrorColumnType(fieldType string) string {
+	switch fieldType {
+	case "boolean", "int":
+		return "INTEGER"
+	case "double", "currency", "percent":
+		return "REAL"
+	default:
+		return "TEXT"
+	}
+}
+
+// Build the CREATE TABLE statement for a new mirror table
+func mirrorCreateTableSql(object string, fields []mirrorField) string {
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		column := fmt.Sprintf("%s %s", quoteMirrorIdent(f.Name), mirrorColumnType(f.Type))
+		if f.Name == "Id" {
+			column += " PRIMARY KEY"
+		}
+		columns[i] = column
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", quoteMirrorIdent(object), strings.Join(columns, ", "))
+}
+
+// Build the statement used to insert or update a mirrored record.  Only the
+// named columns are updated so records can be loaded in groups of fields.
+func mirrorUpsertSql(object string, names []string) string {
+	columns := make([]string, len(names))
+	placeholders := make([]string, len(names))
+	var updates []string
+	for i, name := range names {
+		columns[i] = quoteMirrorIdent(name)
+		placeholders[i] = "?"
+		if name != "Id" {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", columns[i], columns[i]))
+		}
+	}
+	upsert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (\"Id\") DO ", quoteMirrorIdent(object), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	if len(updates) == 0 {
+		return upsert + "NOTHING"
+	}
+	return upsert + "UPDATE SET " + strings.Join(updates, ", ")
+}
+
+// Split the fields of an object into groups that can each be queried without
+// the query URL exceeding mirrorMaxQueryLength.  Each group includes the Id
+// so its records can be matched with the records of the other groups.
+func mirrorFieldGroups(object string, fields []mirrorField, where string) (groups [][]mirrorField) {
+	var ids, others []mirrorField
+	for _, f := range fields {
+		if f.Name == "Id" {
+			ids = append(ids, f)
+		} else {
+			others = append(others, f)
+		}
+	}
+	queryLength := func(group []mirrorField) int {
+		names := make([]string, len(group))
+		for i, f := range group {
+			names[i] = f.Name
+		}
+		return len(url.QueryEscape(fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(names, ", "), object, where)))
+	}
+	group := append([]mirrorField{}, ids...)
+	for _, f := range others {
+		if len(group) > len(ids) && queryLength(append(group, f)) > mirrorMaxQueryLength {
+			groups = append(groups, group)
+			group = append([]mirrorField{}, ids...)
+		}
+		group = append(group, f)
+	}
+	groups = append(groups, group)
+	return
+}
+
+func mirrorValue(field mirrorField, value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if field.Type == "int" {
+			return int64(v)
+		}
+	case map[string]interface{}, []interface{}:
+		return fmt.Sprint(v)
+	}
+	return value
+}
+
+// Convert a datetime returned by the API to a SOQL datetime literal.
+// Fractional seconds are dropped, so syncs compare with >= and may re-read
+// records changed within the same second.
+func mirrorSoqlDatetime(value string) string {
+	t, err := time.Parse(mirrorDatetimeLayout, value)
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+func hasMirrorField(fields []mirrorField, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteMirrorIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
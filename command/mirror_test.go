@@ -0,0 +1,106 @@
+package command
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	. "github.com/ForceCLI/force/lib"
+)
+
+func TestMirrorFields(t *testing.T) {
+	sobject := ForceSobject{
+		"fields": []interface{}{
+			map[string]interface{}{"name": "Id", "type": "id"},
+			map[string]interface{}{"name": "BillingAddress", "type": "address"},
+			map[string]interface{}{"name": "NumberOfEmployees", "type": "int"},
+			map[string]interface{}{"name": "AnnualRevenue", "type": "currency"},
+		},
+	}
+	fields := mirrorFields(sobject)
+	if len(fields) != 3 {
+		t.Fatalf("Expected 3 fields, got %d", len(fields))
+	}
+
+	got := mirrorCreateTableSql("Account", fields)
+	expected := `CREATE TABLE "Account" ("Id" TEXT PRIMARY KEY, "NumberOfEmployees" INTEGER, "AnnualRevenue" REAL)`
+	if got != expected {
+		t.Errorf("Expected %s got %s", expected, got)
+	}
+}
+
+func TestMirrorSoqlDatetime(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"2019-06-01T12:30:45.000+0000", "2019-06-01T12:30:45Z"},
+		{"2019-06-01T12:30:45.000-0700", "2019-06-01T19:30:45Z"},
+		{"not a date", ""},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.input, func(t *testing.T) {
+			got := mirrorSoqlDatetime(test.input)
+			if got != test.expected {
+				t.Errorf("Expected %v got %v for %s", test.expected, got, test.input)
+			}
+		})
+	}
+}
+
+func TestMirrorFieldGroups(t *testing.T) {
+	fields := []mirrorField{{Name: "Id", Type: "id"}}
+	for i := 0; i < 1000; i++ {
+		fields = append(fields, mirrorField{Name: fmt.Sprintf("Custom_Field_%d__c", i), Type: "string"})
+	}
+	groups := mirrorFieldGroups("Account", fields, " WHERE SystemModstamp >= 2019-06-01T12:30:45Z")
+	if len(groups) < 2 {
+		t.Fatalf("Expected the fields to be split, got %d groups", len(groups))
+	}
+	total := 0
+	for _, group := range groups {
+		if group[0].Name != "Id" {
+			t.Errorf("Expected each group to start with Id, got %s", group[0].Name)
+		}
+		total += len(group) - 1
+	}
+	if total != len(fields)-1 {
+		t.Errorf("Expected %d fields in the groups, got %d", len(fields)-1, total)
+	}
+
+	groups = mirrorFieldGroups("Account", fields[:3], "")
+	if len(groups) != 1 || len(groups[0]) != 3 {
+		t.Errorf("Expected a single group of 3 fields, got %v", groups)
+	}
+}
+
+func TestMirrorUpsertSql(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	fields := []mirrorField{{Name: "Id", Type: "id"}, {Name: "Name", Type: "string"}, {Name: "Phone", Type: "phone"}}
+	if _, err = db.Exec(mirrorCreateTableSql("Account", fields)); err != nil {
+		t.Fatal(err)
+	}
+	for _, upsert := range []struct {
+		names  []string
+		values []interface{}
+	}{
+		{[]string{"Id", "Name"}, []interface{}{"001000000000001AAA", "Acme"}},
+		{[]string{"Id", "Phone"}, []interface{}{"001000000000001AAA", "555-1212"}},
+	} {
+		if _, err = db.Exec(mirrorUpsertSql("Account", upsert.names), upsert.values...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var name, phone string
+	if err = db.QueryRow(`SELECT "Name", "Phone" FROM "Account"`).Scan(&name, &phone); err != nil {
+		t.Fatal(err)
+	}
+	if name != "Acme" || phone != "555-1212" {
+		t.Errorf("Expected each group's columns to be kept, got %s, %s", name, phone)
+	}
+}
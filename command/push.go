@@ -30,9 +30,20 @@ Examples:
   force push -t StaticResource -n MyResource
   force push -t ApexClass
   force push -f metadata/classes/MyClass.cls
+  force push -f metadata/lwc/myComponent
   force push -checkonly -test MyClass_Test metadata/classes/MyClass.cls
+  force push -checkonly -suite Smoke metadata/classes/MyClass.cls
   force push -n MyApex -n MyObject__c
   git diff HEAD^ --name-only --diff-filter=ACM | force push -f -
+  force push -async metadata/classes/MyClass.cls
+  force push -since origin/master
+  force push -since v1.2 -dry-run
+  force push -since v1.2 -until v1.3
+  force push -since v1.2..v1.3
+  force push -split metadata
+  force push -snapshot metadata/classes/MyClass.cls
+  force push -to dev,uat,admin@example.com metadata
+  force push -tooling metadata/classes/MyClass.cls metadata/pages/MyPage.page
 
 Deployment Options
   -rollbackonerror, -r    Indicates whether any failure causes a complete rollback
@@ -42,8 +53,36 @@ Deployment Options
   -allowmissingfiles, -m  Specifies whether a deploy succeeds even if files missing
   -autoupdatepackage, -u  Auto add files to the package if missing
   -test                   Run tests in class (implies -l RunSpecifiedTests)
+  -suite                  Run the classes in test suites (implies -l RunSpecifiedTests)
   -testlevel, -l          Set test level (NoTestRun, RunSpecifiedTests, RunLocalTests, RunAllTestsInOrg)
   -ignorewarnings, -i     Indicates if warnings should fail deployment or not
+  -async                  Start the deploy and print its id without waiting for it to finish
+  -report                 Write test and deploy results, e.g. junit=results.xml,json=results.json
+  -dry-run                Print the package.xml that would be deployed instead of deploying
+  -snapshot               Save the org's versions of the components before deploying so
+                          the deploy can be undone with "force rollback".  Wildcard (*)
+                          members are expanded from the files being deployed.
+  -to                     Deploy to each of a comma-separated list of logins instead of
+                          the active login
+  -workers                Number of orgs to deploy to at once with -to (default 4)
+  -stop-on-failure        With -to, skip the orgs not yet deployed to when a deploy fails
+  -split                  Deploy in waves: objects and fields, code, other metadata, and
+                          then layouts and profiles.  Use for deploys over the Metadata
+                          API limits of 39 MB and 10,000 files.  The test level and tests
+                          apply only to the code wave, or the last wave if there's no
+                          code; other waves run no tests.  Production orgs require tests
+                          for every deploy that includes Apex, so a code wave too large
+                          to deploy in one part can only be split in a sandbox.
+  -tooling                Save Apex classes, triggers, pages, and components through the
+                          Tooling API, which is faster than a deploy for a few files
+
+Pushing Changes
+  -since <git ref>        Push metadata added or changed since the git ref, including
+                          untracked files that aren't ignored.  Metadata deleted since
+                          the ref is removed using destructiveChanges.xml.  A range of
+                          refs, <ref>..<ref>, can be given instead of using -until.
+  -until <git ref>        With -since, push the changes between the two refs.  Files
+                          are read from the -until ref instead of the working tree.
 `,
 	MaxExpectedArgs: -1,
 }
@@ -52,6 +91,10 @@ var (
 	namePaths     = make(map[string]string)
 	resourcepaths metaName
 	metaFolder    string
+	sinceRef      string
+	untilRef      string
+	dryRun        bool
+	toolingPush   bool
 )
 
 func init() {
@@ -72,22 +115,34 @@ func init() {
 	cmdPush.Flag.BoolVar(autoUpdatePackageFlag, "u", false, "set auto update package")
 	cmdPush.Flag.BoolVar(ignoreWarningsFlag, "ignorewarnings", false, "set ignore warnings")
 	cmdPush.Flag.BoolVar(ignoreWarningsFlag, "i", false, "set ignore warnings")
+	cmdPush.Flag.BoolVar(asyncDeployFlag, "async", false, "start deploy without waiting for it to finish")
+	cmdPush.Flag.StringVar(reportFlag, "report", "", "write results as junit=path.xml,json=path.json")
+	cmdPush.Flag.BoolVar(&dryRun, "dry-run", false, "print package.xml instead of deploying")
+	cmdPush.Flag.BoolVar(splitDeployFlag, "split", false, "deploy in dependency-ordered waves")
+	cmdPush.Flag.BoolVar(snapshotFlag, "snapshot", false, "save a snapshot of the components before deploying")
+	cmdPush.Flag.Var(&deployLogins, "to", "logins to deploy to")
+	cmdPush.Flag.IntVar(deployWorkersFlag, "workers", 4, "number of orgs to deploy to at once")
+	cmdPush.Flag.BoolVar(stopOnFailureFlag, "stop-on-failure", false, "stop deploying to orgs after a failure")
 
 	// Ways to push
 	cmdPush.Flag.Var(&resourcepaths, "f", "Path to resource(s)")
 	cmdPush.Flag.Var(&resourcepaths, "filepath", "Path to resource(s)")
 	cmdPush.Flag.Var(&testsToRun, "test", "Test(s) to run")
+	cmdPush.Flag.Var(&testSuites, "suite", "test suites to run")
 	cmdPush.Flag.StringVar(&metadataType, "t", "", "Metatdata type")
 	cmdPush.Flag.StringVar(&metadataType, "type", "", "Metatdata type")
 	cmdPush.Flag.Var(&metadataName, "name", "name of metadata object")
 	cmdPush.Flag.Var(&metadataName, "n", "names of metadata object")
+	cmdPush.Flag.StringVar(&sinceRef, "since", "", "push changes since git ref")
+	cmdPush.Flag.StringVar(&untilRef, "until", "", "push changes up to git ref instead of the working tree")
+	cmdPush.Flag.BoolVar(&toolingPush, "tooling", false, "save Apex and Visualforce through the Tooling API")
 	cmdPush.Run = runPush
 }
 
-func replaceAuraComponentWithBundle(inputPathToFile string) string {
+func replaceComponentWithBundle(inputPathToFile string) string {
 	dirPart, filePart := filepath.Split(inputPathToFile)
 	dirPart = filepath.Dir(dirPart)
-	if strings.Contains(dirPart, "aura") && filepath.Ext(filePart) != "" && filepath.Base(filepath.Dir(dirPart)) == "aura" {
+	if filepath.Ext(filePart) != "" && isBundleDir(filepath.Base(filepath.Dir(dirPart))) {
 		inputPathToFile = dirPart
 	}
 	return inputPathToFile
@@ -102,6 +157,31 @@ func runPush(cmd *Command, args []string) {
 	// Treat trailing args as file paths
 	resourcepaths = append(resourcepaths, args...)
 
+	if sinceRef != "" {
+		if len(resourcepaths) > 0 || len(metadataType) > 0 {
+			ErrorAndExit("The -since parameter cannot be combined with paths or metadata types.")
+		}
+		since, until := sinceRef, untilRef
+		if refs := strings.SplitN(sinceRef, "..", 2); len(refs) == 2 && !strings.HasPrefix(refs[1], ".") {
+			if untilRef != "" {
+				ErrorAndExit("The -until parameter cannot be combined with a range of refs.")
+			}
+			since, until = refs[0], refs[1]
+			// As with git, an omitted ref in a range is HEAD
+			if since == "" {
+				since = "HEAD"
+			}
+			if until == "" {
+				until = "HEAD"
+			}
+		}
+		pushChangesSince(since, until)
+		return
+	}
+	if untilRef != "" {
+		ErrorAndExit("The -until parameter requires -since.")
+	}
+
 	if len(resourcepaths) == 1 && resourcepaths[0] == "-" {
 		resourcepaths = make(metaName, 0)
 		scanner := bufio.NewScanner(os.Stdin)
@@ -117,16 +197,24 @@ func runPush(cmd *Command, args []string) {
 		ErrorAndExit("Nothing to push. Please specify metadata components to deploy.")
 	}
 
+	if toolingPush {
+		if len(resourcepaths) == 0 {
+			ErrorAndExit("The -tooling parameter requires file paths.")
+		}
+		pushByToolingApi(resourcepaths)
+		return
+	}
+
 	if len(resourcepaths) > 0 {
 		// It's not a package but does have a path. This could be a path to a file
 		// or to a folder. If it is a folder, we pickup the resources a different
 		// way than if it's a file.
 
-		// Replace aura file reference with full bundle folder because only the
-		// main component can be deployed by itself.
+		// Replace aura and lwc file references with the full bundle folder
+		// because only the main component can be deployed by itself.
 		resorucepathsToPush := make(metaName, 0)
 		for _, fsPath := range resourcepaths {
-			resorucepathsToPush = append(resorucepathsToPush, replaceAuraComponentWithBundle(fsPath))
+			resorucepathsToPush = append(resorucepathsToPush, replaceComponentWithBundle(fsPath))
 		}
 		resourcepaths = resorucepathsToPush
 
@@ -248,7 +336,7 @@ func findMetadataTypeFolder(mdtype string, root string) (folder string) {
 		if firstEl == mdtype {
 			// This is sufficient for MD that does not have sub folders (classes, pages, etc)
 			// It is NOT sufficient for aura bundles
-			if mdtype == "AuraDefinitionBundle" {
+			if mdtype == "AuraDefinitionBundle" || mdtype == "LightningComponentBundle" {
 				// Need the parent of this folder to get all aura bundles in the directory
 				folder = filepath.Dir(filepath.Dir(path))
 			} else {
@@ -334,6 +422,29 @@ func pushByMetadataType() {
 		return
 	}
 
+	// Push whole Lightning Web Component bundles
+	if filepath.Base(metaFolder) == "lwc" {
+		bundles, err := ioutil.ReadDir(metaFolder)
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+		for _, bundle := range bundles {
+			if !bundle.IsDir() {
+				continue
+			}
+			if len(metadataName) == 0 {
+				files = append(files, filepath.Join(metaFolder, bundle.Name()))
+			}
+			for _, name := range metadataName {
+				if bundle.Name() == name {
+					files = append(files, filepath.Join(metaFolder, bundle.Name()))
+				}
+			}
+		}
+		PushByPaths(files, true, namePaths, deployOpts())
+		return
+	}
+
 	filepath.Walk(metaFolder, func(path string, f os.FileInfo, err error) error {
 		// Check to see if this is a folder. This will be the case with static resources
 		// that have been unpacked.  Not entirely sure if this is the only time we will
@@ -431,6 +542,17 @@ func deployOpts() *ForceDeployOptions {
 	if *runAllTestsFlag {
 		opts.TestLevel = "RunAllTestsInOrg"
 	}
-	opts.RunTests = testsToRun
+	opts.RunTests = deployTests()
+	opts.Async = *asyncDeployFlag
+	opts.Report = *reportFlag
+	opts.DryRun = dryRun
+	opts.Split = *splitDeployFlag
+	opts.Snapshot = *snapshotFlag
+	opts.To = deployLogins
+	opts.Workers = *deployWorkersFlag
+	opts.StopOnFailure = *stopOnFailureFlag
+	validateReportSpec(opts.Report)
+	validateSplitDeploy(&opts)
+	validateMultiOrgDeploy(&opts)
 	return &opts
 }
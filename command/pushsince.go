@@ -0,0 +1,252 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ForceCLI/force/config"
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+// Metadata directories whose components are folders of files that must be
+// deployed together
+var bundleDirs = []string{"aura", "lwc"}
+
+// Changes to deploy, computed from the files changed in git
+type DeltaChanges struct {
+	// Files and bundle folders to push
+	Paths []string
+	// Unpacked static resource folders that need to be zipped before pushing
+	ResourceFolders []string
+	// Deleted files whose components should be removed from the org
+	Deleted []string
+}
+
+// Push the metadata changed since a git ref, deleting metadata whose files
+// have been removed.  If until isn't empty, the changes between the two refs
+// are pushed using the files at until instead of the working tree.
+func pushChangesSince(ref string, until string) {
+	root, err := config.GetSourceDir()
+	ExitIfNoSourceDir(err)
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		ErrorAndExit(err.Error())
+	}
+
+	changed, deleted, err := GitChangedFiles(ref, until)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	if until != "" {
+		var tempDir string
+		tempDir, err = ioutil.TempDir("", "force-push")
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+		defer os.RemoveAll(tempDir)
+		refRoot := filepath.Join(tempDir, filepath.Base(root))
+		if changed, deleted, err = CheckoutDeltaFiles(root, refRoot, until, changed, deleted); err != nil {
+			ErrorAndExit(err.Error())
+		}
+		root = refRoot
+	}
+	delta := ComputeDeltaChanges(root, changed, deleted)
+	for _, folder := range delta.ResourceFolders {
+		zipResource(folder, "")
+	}
+
+	if force, err := ActiveForce(); err == nil {
+		force.Metadata.CacheMetadataTypes()
+	}
+	pb := NewPushBuilder()
+	badPaths := pb.AddPaths(delta.Paths, namePaths)
+	if len(badPaths) > 0 {
+		ErrorAndExit("Could not add the following files:\n {%v}", strings.Join(badPaths, "\n"))
+	}
+	for _, fpath := range delta.Deleted {
+		if _, err := pb.AddDeletedFile(fpath); err != nil {
+			ErrorAndExit(err.Error())
+		}
+	}
+	if len(pb.Metadata) == 0 && len(pb.DestructiveChanges) == 0 {
+		if until != "" {
+			fmt.Printf("No metadata changes between %s and %s\n", ref, until)
+		} else {
+			fmt.Printf("No metadata changes since %s\n", ref)
+		}
+		return
+	}
+	PushPackage(&pb, false, namePaths, deployOpts())
+}
+
+// CheckoutDeltaFiles writes the files needed to push the changed and deleted
+// files within the source directory, root, as they are at a git ref into
+// refRoot.  File contents are read with git show instead of from the working
+// tree.  Whole bundles, unpacked static resources, and companion -meta.xml
+// files are written along with the changed files.  The changed and deleted
+// paths are returned relative to refRoot.
+func CheckoutDeltaFiles(root string, refRoot string, ref string, changed []string, deleted []string) (refChanged []string, refDeleted []string, err error) {
+	tree, err := GitTreeFiles(ref)
+	if err != nil {
+		return
+	}
+	needed := make(map[string]bool)
+	var folders []string
+	for _, path := range append(append([]string{}, changed...), deleted...) {
+		parts, ok := deltaPathParts(root, path)
+		if !ok {
+			continue
+		}
+		if (isBundleDir(parts[0]) || parts[0] == "staticresources") && len(parts) > 2 {
+			folders = append(folders, filepath.Join(root, parts[0], parts[1])+string(os.PathSeparator))
+			continue
+		}
+		needed[path] = true
+		needed[path+"-meta.xml"] = true
+		needed[strings.TrimSuffix(path, "-meta.xml")] = true
+	}
+
+	for _, path := range tree {
+		if !needed[path] && !hasAnyPrefix(path, folders) {
+			continue
+		}
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			continue
+		}
+		var data []byte
+		if data, err = GitShowFile(ref, path); err != nil {
+			return
+		}
+		dest := filepath.Join(refRoot, rel)
+		if err = os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return
+		}
+		if err = ioutil.WriteFile(dest, data, 0644); err != nil {
+			return
+		}
+	}
+
+	move := func(paths []string) (moved []string) {
+		for _, path := range paths {
+			if rel, err := filepath.Rel(root, path); err == nil {
+				moved = append(moved, filepath.Join(refRoot, rel))
+			}
+		}
+		return
+	}
+	refChanged = move(changed)
+	refDeleted = move(deleted)
+	return
+}
+
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ComputeDeltaChanges works out what to deploy for the files changed and
+// deleted within the source directory, root.  Companion files are included:
+// whole bundles are pushed when any of their files change, and unpacked
+// static resources are re-zipped.  Files outside of root are ignored.
+func ComputeDeltaChanges(root string, changed []string, deleted []string) (delta DeltaChanges) {
+	seen := make(map[string]bool)
+	push := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			delta.Paths = append(delta.Paths, path)
+		}
+	}
+	zip := func(folder string) {
+		if !seen[folder] {
+			seen[folder] = true
+			delta.ResourceFolders = append(delta.ResourceFolders, folder)
+			push(folder + ".resource")
+		}
+	}
+	destroy := func(path string) {
+		if !seen["-"+path] {
+			seen["-"+path] = true
+			delta.Deleted = append(delta.Deleted, path)
+		}
+	}
+
+	for _, path := range changed {
+		parts, ok := deltaPathParts(root, path)
+		if !ok {
+			continue
+		}
+		switch {
+		case isBundleDir(parts[0]) && len(parts) > 2:
+			push(filepath.Join(root, parts[0], parts[1]))
+		case parts[0] == "staticresources" && len(parts) > 2:
+			zip(filepath.Join(root, parts[0], parts[1]))
+		default:
+			push(path)
+		}
+	}
+
+	for _, path := range deleted {
+		parts, ok := deltaPathParts(root, path)
+		if !ok {
+			continue
+		}
+		switch {
+		case isBundleDir(parts[0]) && len(parts) > 2:
+			bundle := filepath.Join(root, parts[0], parts[1])
+			if pathExistsOnDisk(bundle) {
+				push(bundle)
+			} else {
+				destroy(path)
+			}
+		case parts[0] == "staticresources" && len(parts) > 2:
+			folder := filepath.Join(root, parts[0], parts[1])
+			if pathExistsOnDisk(folder) {
+				zip(folder)
+			}
+		case strings.HasSuffix(path, "-meta.xml") && pathExistsOnDisk(strings.TrimSuffix(path, "-meta.xml")):
+			push(strings.TrimSuffix(path, "-meta.xml"))
+		default:
+			destroy(path)
+		}
+	}
+	return
+}
+
+// Split a path into its components relative to root.  Paths outside root and
+// package manifests are skipped.
+func deltaPathParts(root string, path string) (parts []string, ok bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return
+	}
+	parts = strings.Split(rel, string(os.PathSeparator))
+	if len(parts) < 2 {
+		// package.xml and destructiveChanges.xml at the top of the source
+		// directory
+		return
+	}
+	ok = true
+	return
+}
+
+func isBundleDir(dir string) bool {
+	for _, b := range bundleDirs {
+		if b == dir {
+			return true
+		}
+	}
+	return false
+}
+
+func pathExistsOnDisk(path string) bool {
+	exists, _ := pathExists(path)
+	return exists
+}
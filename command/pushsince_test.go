@@ -0,0 +1,147 @@
+package command_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/ForceCLI/force/command"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PushSince", func() {
+	Describe("ComputeDeltaChanges", func() {
+		var (
+			tempDir string
+			root    string
+		)
+
+		BeforeEach(func() {
+			tempDir, _ = ioutil.TempDir("", "pushsince-test")
+			root = filepath.Join(tempDir, "src")
+			os.MkdirAll(filepath.Join(root, "aura", "myCmp"), 0755)
+			os.MkdirAll(filepath.Join(root, "staticresources", "myLib", "js"), 0755)
+			os.MkdirAll(filepath.Join(root, "classes"), 0755)
+			ioutil.WriteFile(filepath.Join(root, "classes", "Kept.cls"), []byte("class Kept {}"), 0644)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should push the whole bundle when a bundle file changes", func() {
+			delta := ComputeDeltaChanges(root, []string{filepath.Join(root, "aura", "myCmp", "myCmpController.js")}, nil)
+			Expect(delta.Paths).To(Equal([]string{filepath.Join(root, "aura", "myCmp")}))
+		})
+
+		It("should push the whole Lightning Web Component when a bundle file changes", func() {
+			delta := ComputeDeltaChanges(root, []string{filepath.Join(root, "lwc", "myLwc", "myLwc.html")}, nil)
+			Expect(delta.Paths).To(Equal([]string{filepath.Join(root, "lwc", "myLwc")}))
+		})
+
+		It("should zip unpacked static resources", func() {
+			delta := ComputeDeltaChanges(root, []string{filepath.Join(root, "staticresources", "myLib", "js", "lib.js")}, nil)
+			Expect(delta.ResourceFolders).To(Equal([]string{filepath.Join(root, "staticresources", "myLib")}))
+			Expect(delta.Paths).To(Equal([]string{filepath.Join(root, "staticresources", "myLib.resource")}))
+		})
+
+		It("should ignore files outside the source directory and package.xml", func() {
+			delta := ComputeDeltaChanges(root, []string{filepath.Join(tempDir, "README.md"), filepath.Join(root, "package.xml")}, nil)
+			Expect(delta.Paths).To(BeEmpty())
+		})
+
+		It("should delete removed components", func() {
+			delta := ComputeDeltaChanges(root, nil, []string{
+				filepath.Join(root, "classes", "Gone.cls"),
+				filepath.Join(root, "aura", "oldCmp", "oldCmp.cmp"),
+			})
+			Expect(delta.Deleted).To(Equal([]string{
+				filepath.Join(root, "classes", "Gone.cls"),
+				filepath.Join(root, "aura", "oldCmp", "oldCmp.cmp"),
+			}))
+		})
+
+		It("should push bundles and components that still exist", func() {
+			delta := ComputeDeltaChanges(root, nil, []string{
+				filepath.Join(root, "aura", "myCmp", "myCmp.css"),
+				filepath.Join(root, "classes", "Kept.cls-meta.xml"),
+			})
+			Expect(delta.Deleted).To(BeEmpty())
+			Expect(delta.Paths).To(Equal([]string{
+				filepath.Join(root, "aura", "myCmp"),
+				filepath.Join(root, "classes", "Kept.cls"),
+			}))
+		})
+	})
+
+	Describe("CheckoutDeltaFiles", func() {
+		var (
+			tempDir string
+			root    string
+			wd      string
+		)
+
+		write := func(name string, content string) {
+			os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755)
+			ioutil.WriteFile(filepath.Join(root, name), []byte(content), 0644)
+		}
+		run := func(args ...string) {
+			cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+			cmd.Dir = tempDir
+			out, err := cmd.CombinedOutput()
+			Expect(err).ToNot(HaveOccurred(), string(out))
+		}
+
+		BeforeEach(func() {
+			if _, err := exec.LookPath("git"); err != nil {
+				Skip("git not installed")
+			}
+			tempDir, _ = ioutil.TempDir("", "pushsince-test")
+			tempDir, _ = filepath.EvalSymlinks(tempDir)
+			root = filepath.Join(tempDir, "src")
+			write("classes/Changed.cls", "class Changed {}")
+			write("classes/Changed.cls-meta.xml", "<ApexClass/>")
+			write("classes/Other.cls", "class Other {}")
+			write("aura/myCmp/myCmp.cmp", "<aura:component/>")
+			write("aura/myCmp/myCmpController.js", "({})")
+			run("init", "-q")
+			run("add", ".")
+			run("commit", "-q", "-m", "initial")
+			wd, _ = os.Getwd()
+			os.Chdir(tempDir)
+		})
+
+		AfterEach(func() {
+			os.Chdir(wd)
+			os.RemoveAll(tempDir)
+		})
+
+		It("should write the files needed to push from the ref instead of the working tree", func() {
+			write("classes/Changed.cls", "class Changed { v2 }")
+			write("aura/myCmp/myCmpController.js", "({ v2 })")
+			run("commit", "-q", "-a", "-m", "second")
+			write("classes/Changed.cls", "class Changed { working }")
+
+			refRoot := filepath.Join(tempDir, "ref", "src")
+			changed, deleted, err := CheckoutDeltaFiles(root, refRoot, "HEAD", []string{
+				filepath.Join(root, "classes", "Changed.cls"),
+				filepath.Join(root, "aura", "myCmp", "myCmpController.js"),
+			}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(Equal([]string{
+				filepath.Join(refRoot, "classes", "Changed.cls"),
+				filepath.Join(refRoot, "aura", "myCmp", "myCmpController.js"),
+			}))
+			Expect(deleted).To(BeEmpty())
+
+			data, _ := ioutil.ReadFile(filepath.Join(refRoot, "classes", "Changed.cls"))
+			Expect(string(data)).To(Equal("class Changed { v2 }"))
+			Expect(filepath.Join(refRoot, "classes", "Changed.cls-meta.xml")).To(BeAnExistingFile())
+			Expect(filepath.Join(refRoot, "aura", "myCmp", "myCmp.cmp")).To(BeAnExistingFile())
+			Expect(filepath.Join(refRoot, "classes", "Other.cls")).ToNot(BeAnExistingFile())
+		})
+	})
+})
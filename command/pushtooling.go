@@ -0,0 +1,93 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ForceCLI/force/desktop"
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+// Save Apex and Visualforce files through the Tooling API instead of
+// deploying them
+func pushByToolingApi(paths []string) {
+	files, err := toolingFilesForPaths(paths)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	if len(files) == 0 {
+		ErrorAndExit("No Apex or Visualforce files to save.")
+	}
+
+	force, _ := ActiveForce()
+	problems, err := force.SaveToolingFiles(files)
+	if err != nil {
+		desktop.NotifySuccess("push", false)
+		ErrorAndExit(err.Error())
+	}
+	desktop.NotifySuccess("push", len(problems) == 0)
+	if len(problems) > 0 {
+		fmt.Printf("\nFailures - %d\n", len(problems))
+		for _, problem := range problems {
+			fmt.Println(formatToolingSaveError(problem))
+		}
+		ErrorAndExit("Some components failed to save")
+	}
+	fmt.Printf("\nSaved - %d\n", len(files))
+	for _, file := range files {
+		fmt.Printf("\t%s: %s\n", file.Type, file.Name)
+	}
+}
+
+func formatToolingSaveError(problem ToolingSaveError) string {
+	location := problem.Path
+	if location == "" {
+		location = problem.Type + " " + problem.Name
+	}
+	if problem.Line == 0 {
+		return fmt.Sprintf("\"%s\": %s", location, problem.Problem)
+	}
+	return fmt.Sprintf("\"%s\", line %d, column %d: %s", location, problem.Line, problem.Column, problem.Problem)
+}
+
+// Read the files to save.  Directories are searched for Apex and Visualforce
+// files; other files in them are skipped.
+func toolingFilesForPaths(paths []string) (files []ToolingFile, err error) {
+	seen := make(map[string]bool)
+	add := func(fpath string) error {
+		file, err := ReadToolingFile(fpath)
+		if err != nil {
+			return err
+		}
+		if !seen[file.Path] {
+			seen[file.Path] = true
+			files = append(files, file)
+		}
+		return nil
+	}
+	for _, p := range paths {
+		var f os.FileInfo
+		if f, err = os.Stat(p); err != nil {
+			return
+		}
+		if !f.IsDir() {
+			if err = add(p); err != nil {
+				return
+			}
+			continue
+		}
+		err = filepath.Walk(p, func(path string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() || strings.HasSuffix(path, "-meta.xml") || !IsToolingFile(path) {
+				return err
+			}
+			return add(path)
+		})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
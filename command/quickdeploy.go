@@ -9,25 +9,31 @@ import (
 )
 
 var cmdQuickDeploy = &Command{
-	Usage: "quickdeploy [deployment options] <validation id>",
+	Usage: "quickdeploy [deployment options] (<validation id> | -latest)",
 	Short: "Quick deploy validation id",
 	Long: `
 Quick deploy validation id
 
 Deployment Options
   -verbose, -v	  Provide detailed feedback on operation
+  -latest         Deploy the most recent validation that can still be quick deployed
+
+Validations can be listed with "force deploy list".
 
 Examples:
 
   force quickdeploy 0Af1200000FFbBzCAL
 
+  force quickdeploy -latest
+
   force quickdeploy -v 0Af0b000000ZvXH
 `,
 	MaxExpectedArgs: -1,
 }
 
 var (
-	verboseFlag = cmdQuickDeploy.Flag.Bool("verbose", false, "give more verbose output")
+	verboseFlag      = cmdQuickDeploy.Flag.Bool("verbose", false, "give more verbose output")
+	latestValidation = cmdQuickDeploy.Flag.Bool("latest", false, "deploy the most recent validation")
 )
 
 func init() {
@@ -36,16 +42,30 @@ func init() {
 }
 
 func runQuickDeploy(cmd *Command, args []string) {
-	if len(args) != 1 {
+	if *latestValidation && len(args) > 0 {
+		ErrorAndExit("Specify either a validation id or -latest")
+	}
+	if !*latestValidation && len(args) != 1 {
 		ErrorAndExit("The quickdeploy command only accepts a single validation id")
 	}
-	quickDeployId := args[0]
 
 	force, err := ActiveForce()
 	if err != nil {
 		ErrorAndExit(err.Error())
 	}
 
+	var quickDeployId string
+	if *latestValidation {
+		validation, err := force.LatestQuickDeployableValidation()
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+		quickDeployId = validation.Id
+		fmt.Printf("Deploying validation %s completed %s by %s\n", validation.Id, validation.CompletedDate.Local().Format("2006-01-02 15:04:05"), validation.CreatedBy)
+	} else {
+		quickDeployId = args[0]
+	}
+
 	result, err := force.Metadata.DeployRecentValidation(quickDeployId)
 	problems := result.Details.ComponentFailures
 	successes := result.Details.ComponentSuccesses
@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	. "github.com/ForceCLI/force/error"
@@ -31,6 +32,10 @@ Usage:
 
   force record delete <object> <id>
 
+  force record merge <object> <masterId> <duplicateId>...
+
+  force record merge <object> <file>
+
 Examples:
 
   force record get User 00Ei0000000000
@@ -44,6 +49,12 @@ Examples:
   force record update User username:user@name.org State:GA
 
   force record delete User 00Ei0000000000
+
+  force record merge Account 001i000000000A 001i000000000B 001i000000000C
+
+  force record merge Account duplicates.csv
+
+A merge file is a CSV file of master id, duplicate id pairs.
 `,
 	MaxExpectedArgs: -1,
 }
@@ -77,6 +88,8 @@ func runRecord(cmd *Command, args []string) {
 			runRecordUpdate(args[1:])
 		case "delete", "remove":
 			runRecordDelete(args[1:])
+		case "merge":
+			runRecordMerge(args[1:])
 		default:
 			ErrorAndExit("no such command: %s", args[0])
 		}
@@ -133,3 +146,100 @@ func runRecordDelete(args []string) {
 	}
 	fmt.Println("Record deleted")
 }
+
+// Maximum number of duplicate records that can be merged into a master
+// record in one request
+const maxMergeDuplicates = 2
+
+func runRecordMerge(args []string) {
+	if len(args) < 2 {
+		ErrorAndExit("must specify object and either master and duplicate ids or a file")
+	}
+	object := args[0]
+	var pairs [][]string
+	if len(args) == 2 {
+		var err error
+		pairs, err = ReadIdPairs(args[1])
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+	} else {
+		for _, duplicateId := range args[2:] {
+			pairs = append(pairs, []string{args[1], duplicateId})
+		}
+	}
+	requests := MergeRequests(pairs)
+	if len(requests) == 0 {
+		ErrorAndExit("no records to merge")
+	}
+
+	force, _ := ActiveForce()
+	results, err := force.Partner.Merge(object, requests)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	failures := 0
+	for _, result := range results {
+		if result.Success {
+			fmt.Printf("%s: merged %s\n", result.Id, strings.Join(result.MergedRecordIds, ", "))
+		} else {
+			failures++
+			fmt.Printf("%s: FAILED %s\n", result.Id, ForcePartnerResult{Errors: result.Errors}.Error())
+		}
+	}
+	fmt.Printf("\n%d succeeded, %d failed\n", len(results)-failures, failures)
+	if failures > 0 {
+		ErrorAndExit("Some records failed to merge")
+	}
+}
+
+// MergeRequests groups master id, duplicate id pairs into merge requests,
+// keeping the order in which master records first appear.
+func MergeRequests(pairs [][]string) (requests []ForceMergeRequest) {
+	var masters []string
+	duplicates := make(map[string][]string)
+	for _, pair := range pairs {
+		master, duplicate := pair[0], pair[1]
+		if _, seen := duplicates[master]; !seen {
+			masters = append(masters, master)
+		}
+		duplicates[master] = append(duplicates[master], duplicate)
+	}
+	for _, master := range masters {
+		ids := duplicates[master]
+		for start := 0; start < len(ids); start += maxMergeDuplicates {
+			end := start + maxMergeDuplicates
+			if end > len(ids) {
+				end = len(ids)
+			}
+			requests = append(requests, ForceMergeRequest{MasterId: master, DuplicateIds: ids[start:end]})
+		}
+	}
+	return
+}
+
+// ReadIdPairs reads a CSV file of id pairs.  A header row is skipped if its
+// first column does not look like a record id.
+func ReadIdPairs(path string) (pairs [][]string, err error) {
+	rows, err := readCSVRows(path)
+	if err != nil {
+		return
+	}
+	for i, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+		first := strings.TrimSpace(row[0])
+		if i == 0 && !isRecordId(first) {
+			continue
+		}
+		pairs = append(pairs, []string{first, strings.TrimSpace(row[1])})
+	}
+	return
+}
+
+var recordIdPattern = regexp.MustCompile(`^[a-zA-Z0-9]{15}([a-zA-Z0-9]{3})?$`)
+
+func isRecordId(value string) bool {
+	return recordIdPattern.MatchString(value)
+}
@@ -0,0 +1,35 @@
+package command_test
+
+import (
+	. "github.com/ForceCLI/force/command"
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Record", func() {
+	Describe("MergeRequests", func() {
+		It("should group duplicates by master record", func() {
+			requests := MergeRequests([][]string{
+				{"001000000000000AAA", "001000000000000BBB"},
+				{"001000000000000CCC", "001000000000000DDD"},
+				{"001000000000000AAA", "001000000000000EEE"},
+			})
+			Expect(requests).To(Equal([]ForceMergeRequest{
+				{MasterId: "001000000000000AAA", DuplicateIds: []string{"001000000000000BBB", "001000000000000EEE"}},
+				{MasterId: "001000000000000CCC", DuplicateIds: []string{"001000000000000DDD"}},
+			}))
+		})
+
+		It("should merge at most two duplicates per request", func() {
+			requests := MergeRequests([][]string{
+				{"001000000000000AAA", "001000000000000BBB"},
+				{"001000000000000AAA", "001000000000000CCC"},
+				{"001000000000000AAA", "001000000000000DDD"},
+			})
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[1].DuplicateIds).To(Equal([]string{"001000000000000DDD"}))
+		})
+	})
+})
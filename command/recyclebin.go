@@ -0,0 +1,219 @@
+package command
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdRecycleBin = &Command{
+	Run:   runRecycleBin,
+	Usage: "recyclebin <command> [<args>]",
+	Short: "List, restore, or purge deleted records",
+	Long: `
+List, restore, or purge deleted records
+
+Ids can be given as arguments, or as the path to a file containing one id per
+line.  A CSV file with an Id column can also be used.
+
+Usage:
+
+  force recyclebin list [<object>]
+
+  force recyclebin undelete <id>... | <file>
+
+  force recyclebin empty <id>... | <file>
+
+Examples:
+
+  force recyclebin list Account
+
+  force recyclebin undelete 001i0000000000AAA 001i0000000000BBB
+
+  force recyclebin undelete deleted-accounts.csv
+
+  force recyclebin empty 001i0000000000AAA
+`,
+	MaxExpectedArgs: -1,
+}
+
+func runRecycleBin(cmd *Command, args []string) {
+	if len(args) == 0 {
+		cmd.PrintUsage()
+	} else {
+		switch args[0] {
+		case "list":
+			runRecycleBinList(args[1:])
+		case "undelete", "restore":
+			runRecycleBinUndelete(args[1:])
+		case "empty", "purge":
+			runRecycleBinEmpty(args[1:])
+		default:
+			ErrorAndExit("no such command: %s", args[0])
+		}
+	}
+}
+
+func runRecycleBinList(args []string) {
+	if len(args) > 1 {
+		ErrorAndExit("must specify at most one object")
+	}
+	force, _ := ActiveForce()
+	if len(args) == 1 {
+		records, err := queryRecycleBin(force, args[0])
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+		DisplayForceRecords(records)
+		return
+	}
+
+	// Without an object, check every object that can be deleted and restored
+	sobjects, err := force.ListSobjects()
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	for _, sobject := range sobjects {
+		queryable, _ := sobject["queryable"].(bool)
+		deletable, _ := sobject["deletable"].(bool)
+		undeletable, _ := sobject["undeletable"].(bool)
+		if !queryable || !deletable || !undeletable {
+			continue
+		}
+		name := sobject["name"].(string)
+		records, err := queryRecycleBin(force, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not query %s: %s\n", name, err.Error())
+			continue
+		}
+		if len(records.Records) > 0 {
+			fmt.Printf("%s\n", name)
+			DisplayForceRecords(records)
+		}
+	}
+}
+
+func queryRecycleBin(force *Force, object string) (records ForceQueryResult, err error) {
+	fields := []string{"Id"}
+	sobject, err := force.GetSobject(object)
+	if err != nil {
+		return
+	}
+	describeFields, _ := sobject["fields"].([]interface{})
+	for _, f := range describeFields {
+		field, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if nameField, _ := field["nameField"].(bool); nameField {
+			fields = append(fields, field["name"].(string))
+			break
+		}
+	}
+	fields = append(fields, "LastModifiedDate", "LastModifiedBy.Name")
+	soql := fmt.Sprintf("SELECT %s FROM %s WHERE IsDeleted = true ORDER BY LastModifiedDate DESC", strings.Join(fields, ", "), object)
+	records, err = force.Query(soql, func(options *QueryOptions) {
+		options.QueryAll = true
+	})
+	return
+}
+
+func runRecycleBinUndelete(args []string) {
+	ids := recordIdsFromArgs(args)
+	force, _ := ActiveForce()
+	results, err := force.Partner.Undelete(ids)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	displayPartnerResults(results, "undeleted")
+}
+
+func runRecycleBinEmpty(args []string) {
+	ids := recordIdsFromArgs(args)
+	force, _ := ActiveForce()
+	results, err := force.Partner.EmptyRecycleBin(ids)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	displayPartnerResults(results, "purged")
+}
+
+// Display the result for each record, exiting with an error if any failed
+func displayPartnerResults(results []ForcePartnerResult, verb string) {
+	failures := 0
+	for _, result := range results {
+		if result.Success {
+			fmt.Printf("%s: %s\n", result.Id, verb)
+		} else {
+			failures++
+			fmt.Printf("%s: FAILED %s\n", result.Id, result.Error())
+		}
+	}
+	fmt.Printf("\n%d succeeded, %d failed\n", len(results)-failures, failures)
+	if failures > 0 {
+		ErrorAndExit("Some records failed")
+	}
+}
+
+// Get record ids from the command line.  A single argument naming an
+// existing file is read as a list of ids.
+func recordIdsFromArgs(args []string) (ids []string) {
+	if len(args) == 0 {
+		ErrorAndExit("must specify record ids or a file containing ids")
+	}
+	if len(args) == 1 {
+		if _, err := os.Stat(args[0]); err == nil {
+			ids, err = ReadRecordIds(args[0])
+			if err != nil {
+				ErrorAndExit(err.Error())
+			}
+			if len(ids) == 0 {
+				ErrorAndExit("no record ids found in %s", args[0])
+			}
+			return
+		}
+	}
+	return args
+}
+
+// ReadRecordIds reads ids from a file.  If the first row has an Id column, as
+// in a CSV export, that column is used; otherwise the first column is used.
+func ReadRecordIds(path string) (ids []string, err error) {
+	rows, err := readCSVRows(path)
+	if err != nil || len(rows) == 0 {
+		return
+	}
+	column := 0
+	for i, heading := range rows[0] {
+		if strings.EqualFold(strings.TrimSpace(heading), "Id") {
+			column = i
+			rows = rows[1:]
+			break
+		}
+	}
+	for _, row := range rows {
+		if column < len(row) {
+			if id := strings.TrimSpace(row[column]); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return
+}
+
+func readCSVRows(path string) (rows [][]string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err = reader.ReadAll()
+	return
+}
@@ -0,0 +1,45 @@
+package command_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/ForceCLI/force/command"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RecycleBin", func() {
+	Describe("ReadRecordIds", func() {
+		var (
+			tempDir string
+		)
+
+		BeforeEach(func() {
+			tempDir, _ = ioutil.TempDir("", "recyclebin-test")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should read one id per line", func() {
+			path := tempDir + "/ids.txt"
+			ioutil.WriteFile(path, []byte("001000000000000AAA\n001000000000000BBB\n"), 0644)
+
+			ids, err := ReadRecordIds(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids).To(Equal([]string{"001000000000000AAA", "001000000000000BBB"}))
+		})
+
+		It("should use the Id column of a CSV file", func() {
+			path := tempDir + "/ids.csv"
+			ioutil.WriteFile(path, []byte("Name,Id\nAcme,001000000000000AAA\n\"Foo, Inc\",001000000000000BBB\n"), 0644)
+
+			ids, err := ReadRecordIds(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids).To(Equal([]string{"001000000000000AAA", "001000000000000BBB"}))
+		})
+	})
+})
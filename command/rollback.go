@@ -0,0 +1,103 @@
+package command
+
+import (
+	"fmt"
+
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdRollback = &Command{
+	Run:   runRollback,
+	Usage: "rollback [-checkonly] [-testlevel <level>] [-test <class>]... [<snapshot>]",
+	Short: "Roll back a deploy to a snapshot",
+	Long: `
+Roll back a deploy to a snapshot taken by "force push -snapshot" or
+"force import -snapshot"
+
+The components in the snapshot are redeployed, and the components that the
+deploy created are deleted.  Snapshots are kept in the .force-snapshots
+directory and named by the time they were taken.  With no snapshot, the
+available snapshots are listed.
+
+The rollback is deployed with rollbackOnError set, so a rollback that fails
+leaves the org unchanged.  Rolling back Apex in a production org requires
+tests to be run; use -testlevel or -test as when pushing.
+
+Rollback Options
+  -checkonly, -c      Validate the rollback without saving it
+  -test               Run tests in class (implies -l RunSpecifiedTests)
+  -testlevel, -l      Set test level (NoTestRun, RunSpecifiedTests, RunLocalTests, RunAllTestsInOrg)
+
+Examples:
+
+  force rollback
+
+  force rollback 20190415-103012
+
+  force rollback -c .force-snapshots/20190415-103012
+
+  force rollback -l RunLocalTests 20190415-103012
+`,
+	MaxExpectedArgs: 1,
+}
+
+var (
+	rollbackCheckOnly bool
+	rollbackTestLevel string
+	rollbackTests     metaName
+)
+
+func init() {
+	cmdRollback.Flag.BoolVar(&rollbackCheckOnly, "checkonly", false, "validate rollback without saving")
+	cmdRollback.Flag.BoolVar(&rollbackCheckOnly, "c", false, "validate rollback without saving")
+	cmdRollback.Flag.StringVar(&rollbackTestLevel, "testlevel", "NoTestRun", "set test level")
+	cmdRollback.Flag.StringVar(&rollbackTestLevel, "l", "NoTestRun", "set test level")
+	cmdRollback.Flag.Var(&rollbackTests, "test", "Test(s) to run")
+}
+
+func runRollback(cmd *Command, args []string) {
+	if len(args) == 0 {
+		listSnapshots()
+		return
+	}
+	snapshot, err := LoadSnapshot(args[0])
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	force, _ := ActiveForce()
+	if force.Credentials.UserInfo != nil && snapshot.OrgId != "" && snapshot.OrgId != force.Credentials.UserInfo.OrgId {
+		ErrorAndExit("Snapshot %s was taken from %s, not the active org", snapshot.Name, snapshot.Username)
+	}
+	files, err := snapshot.Files()
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	fmt.Printf("Rolling back to snapshot %s: redeploying %d components and deleting %d new components\n",
+		snapshot.Name, snapshot.Components, snapshot.NewComponents)
+	opts := ForceDeployOptions{
+		CheckOnly:       rollbackCheckOnly,
+		RollbackOnError: true,
+		TestLevel:       rollbackTestLevel,
+		RunTests:        rollbackTests,
+	}
+	result, err := force.Metadata.Deploy(files, opts)
+	if err = ProcessDeployResults(result, false, make(map[string]string), err); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	fmt.Printf("Rolled back to snapshot %s\n", snapshot.Name)
+}
+
+func listSnapshots() {
+	snapshots, err := ListSnapshots()
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	if len(snapshots) == 0 {
+		fmt.Println("No snapshots found")
+		return
+	}
+	for _, s := range snapshots {
+		fmt.Printf("%s  %-30s %d components, %d new\n", s.Name, s.Username, s.Components, s.NewComponents)
+	}
+}
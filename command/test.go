@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"strings"
+	"time"
 
 	"github.com/ForceCLI/force/desktop"
 	. "github.com/ForceCLI/force/error"
@@ -11,7 +12,7 @@ import (
 )
 
 var cmdTest = &Command{
-	Usage: "test (all | classname... | classname.method...)",
+	Usage: "test [-async] (all | classname... | classname.method... | -suite suite...)",
 	Short: "Run apex tests",
 	Long: `
 Run apex tests
@@ -19,7 +20,21 @@ Run apex tests
 Test Options
   -namespace=<namespace>     Select namespace to run test from
   -class=class               Select class to run tests from
+  -suite=<suites>            Run the classes in test suites, e.g. Smoke,Regression
   -v                         Verbose logging
+  -report=<reports>          Write results, e.g. junit=results.xml,json=results.json
+  -async                     Enqueue the tests to run asynchronously and show each
+                             method's result as it finishes
+
+Asynchronous Test Runs
+
+  force test report [-wait] <job id>
+  force test abort <job id>
+
+Asynchronous test runs are identified by their job id, which is printed when
+the run starts.  "force test report <job id>" shows the results so far, or
+with -wait, resumes waiting for the run to finish.  "force test abort <job id>"
+cancels the classes that haven't finished.
 
 Examples:
 
@@ -28,20 +43,32 @@ Examples:
   force test Test1.method1 Test1.method2
   force test -namespace=ns Test4
   force test -class=Test1 method1 method2
+  force test -suite Smoke
   force test -v Test1
+  force test -report junit=test-results.xml all
+  force test -async Test1 Test2 Test3
+  force test report -wait 7071200000Z3bXaAAJ
+  force test abort 7071200000Z3bXaAAJ
 `,
 	MaxExpectedArgs: -1,
 }
 
 func init() {
 	cmdTest.Flag.BoolVar(&verboselogging, "v", false, "set verbose logging")
+	cmdTest.Flag.Var(&testSuites, "suite", "test suites to run")
+	cmdTest.Flag.BoolVar(&asyncTests, "async", false, "run tests asynchronously")
+	cmdTest.Flag.BoolVar(&waitForTests, "wait", false, "wait for an asynchronous test run to finish")
 	cmdTest.Run = runTests
 }
 
 var (
 	namespaceTestFlag = cmdTest.Flag.String("namespace", "", "namespace to run tests in")
 	classFlag         = cmdTest.Flag.String("class", "", "class to run tests from")
+	testReportFlag    = cmdTest.Flag.String("report", "", "write results as junit=path.xml,json=path.json")
 	verboselogging    bool
+	testSuites        metaName
+	asyncTests        bool
+	waitForTests      bool
 )
 
 func RunTests(testRunner TestRunner, tests []string, namespace string) (output TestCoverage, err error) {
@@ -104,13 +131,43 @@ func GenerateResults(output TestCoverage) string {
 }
 
 func runTests(cmd *Command, args []string) {
-	if len(args) < 1 && *classFlag == "" {
+	if len(args) > 0 {
+		switch args[0] {
+		case "report":
+			runTestReport(parseSubcommandFlags(cmd, args[1:]))
+			return
+		case "abort":
+			runTestAbort(parseSubcommandFlags(cmd, args[1:]))
+			return
+		}
+	}
+	if len(args) < 1 && *classFlag == "" && len(testSuites) == 0 {
 		ErrorAndExit("must specify tests to run")
 	}
 	force, _ := ActiveForce()
 	if *classFlag != "" {
 		args = QualifyMethods(*classFlag, args)
 	}
+	if len(testSuites) > 0 {
+		var err error
+		if args, err = addTestSuiteClasses(args, testSuites, force.TestSuiteClasses); err != nil {
+			ErrorAndExit(err.Error())
+		}
+	}
+	validateReportSpec(*testReportFlag)
+	if asyncTests {
+		if *namespaceTestFlag != "" {
+			ErrorAndExit("-namespace cannot be used with -async")
+		}
+		jobId, err := force.RunTestsAsynchronous(args)
+		if err != nil {
+			ErrorAndExit(err.Error())
+		}
+		fmt.Printf("Started test run %s\n", jobId)
+		fmt.Printf("Resume with: force test report -wait %s\n\n", jobId)
+		waitForAsyncTests(force, jobId)
+		return
+	}
 	output, err := RunTests(force.Partner, args, *namespaceTestFlag)
 
 	if err != nil {
@@ -124,6 +181,12 @@ func runTests(cmd *Command, args []string) {
 	results := GenerateResults(output)
 	fmt.Print(results)
 
+	if *testReportFlag != "" {
+		if err := NewTestCoverageReport(output).Write(*testReportFlag); err != nil {
+			ErrorAndExit("Could not write report: %s", err.Error())
+		}
+	}
+
 	success := len(output.FMethodNames) == 0
 	// Handle notifications
 	desktop.NotifySuccess("test", success)
@@ -131,3 +194,109 @@ func runTests(cmd *Command, args []string) {
 		ErrorAndExit("Tests Failed")
 	}
 }
+
+func testJobIdFromArgs(args []string) string {
+	if len(args) != 1 {
+		ErrorAndExit("must specify a single test run job id")
+	}
+	return args[0]
+}
+
+func runTestReport(args []string) {
+	jobId := testJobIdFromArgs(args)
+	force, _ := ActiveForce()
+	validateReportSpec(*testReportFlag)
+	if waitForTests {
+		waitForAsyncTests(force, jobId)
+		return
+	}
+	items, err := force.AsyncTestQueueItems(jobId)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	results, err := force.AsyncTestResults(jobId)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	for _, r := range results {
+		displayAsyncTestResult(r)
+	}
+	displayAsyncTestQueue(items)
+	reportAsyncTests(results)
+}
+
+func runTestAbort(args []string) {
+	jobId := testJobIdFromArgs(args)
+	force, _ := ActiveForce()
+	aborted, err := force.AbortAsyncTests(jobId)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	fmt.Printf("Aborted %d test classes in test run %s\n", aborted, jobId)
+}
+
+// Wait for an asynchronous test run, showing each method's result as it
+// finishes, then report on the run
+func waitForAsyncTests(force *Force, jobId string) {
+	results, items, err := force.WaitForAsyncTests(jobId, 5*time.Second, displayAsyncTestResult)
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	displayAsyncTestQueue(items)
+	output := reportAsyncTests(results)
+
+	success := output.NumberFailures == 0
+	for _, item := range items {
+		if item.Status != "Completed" {
+			success = false
+		}
+	}
+	desktop.NotifySuccess("test", success)
+	if !success {
+		ErrorAndExit("Tests Failed")
+	}
+}
+
+func displayAsyncTestResult(r AsyncTestResult) {
+	if r.Passed() {
+		fmt.Printf("  [%s]  %s::%s\n", strings.ToUpper(r.Outcome), r.ClassName, r.MethodName)
+		return
+	}
+	fmt.Printf("  [FAIL]  %s::%s: %s\n", r.ClassName, r.MethodName, r.Message)
+	if r.StackTrace != "" {
+		fmt.Printf("    %s\n", r.StackTrace)
+	}
+}
+
+// Show the classes that didn't complete successfully
+func displayAsyncTestQueue(items []AsyncTestQueueItem) {
+	counts := make(map[string]int)
+	for _, item := range items {
+		counts[item.Status]++
+		if item.Status == "Failed" || item.Status == "Aborted" {
+			message := item.Status
+			if item.ExtendedStatus != "" {
+				message = fmt.Sprintf("%s %s", item.Status, item.ExtendedStatus)
+			}
+			fmt.Printf("  %s: %s\n", item.ClassName, message)
+		}
+	}
+	var statuses []string
+	for _, status := range []string{"Holding", "Queued", "Preparing", "Processing", "Completed", "Failed", "Aborted"} {
+		if counts[status] > 0 {
+			statuses = append(statuses, fmt.Sprintf("%d %s", counts[status], strings.ToLower(status)))
+		}
+	}
+	fmt.Printf("\nTest classes: %s\n", strings.Join(statuses, ", "))
+}
+
+func reportAsyncTests(results []AsyncTestResult) (output TestCoverage) {
+	output = AsyncTestCoverage(results)
+	fmt.Printf("Tests run: %d, failures: %d\n", output.NumberRun, output.NumberFailures)
+	if *testReportFlag != "" {
+		if err := NewTestCoverageReport(output).Write(*testReportFlag); err != nil {
+			ErrorAndExit("Could not write report: %s", err.Error())
+		}
+	}
+	return
+}
@@ -0,0 +1,139 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+)
+
+var cmdTestSuite = &Command{
+	Run:   runTestSuite,
+	Usage: "testsuite <command> [<args>]",
+	Short: "Manage Apex test suites",
+	Long: `
+Manage Apex test suites
+
+Test suites can be run with "force test -suite" and as the tests of a deploy
+with "force push -suite" or "force import -suite".
+
+Usage:
+
+  force testsuite list
+
+  force testsuite create <suite> [<class>...]
+
+  force testsuite add <suite> <class>...
+
+  force testsuite remove <suite> <class>...
+
+  force testsuite delete <suite>
+
+Examples:
+
+  force testsuite create Smoke AccountTest ContactTest
+
+  force testsuite add Smoke OpportunityTest
+
+  force testsuite remove Smoke ContactTest
+
+  force testsuite list
+`,
+	MaxExpectedArgs: -1,
+}
+
+func runTestSuite(cmd *Command, args []string) {
+	if len(args) == 0 {
+		cmd.PrintUsage()
+		return
+	}
+	subcommand := args[0]
+	args = parseSubcommandFlags(cmd, args[1:])
+	switch subcommand {
+	case "list":
+		runTestSuiteList(args)
+	case "create":
+		runTestSuiteCreate(args)
+	case "add":
+		runTestSuiteAdd(args)
+	case "remove":
+		runTestSuiteRemove(args)
+	case "delete":
+		runTestSuiteDelete(args)
+	default:
+		ErrorAndExit("no such command: %s", subcommand)
+	}
+}
+
+func runTestSuiteList(args []string) {
+	if len(args) > 0 {
+		ErrorAndExit("too many arguments")
+	}
+	force, _ := ActiveForce()
+	suites, err := force.ListTestSuites()
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	if len(suites) == 0 {
+		fmt.Println("No test suites found")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "SUITE\tCLASSES")
+	for _, suite := range suites {
+		fmt.Fprintf(w, "%s\t%s\n", suite.Name, strings.Join(suite.Classes, ", "))
+	}
+	w.Flush()
+}
+
+func runTestSuiteCreate(args []string) {
+	if len(args) < 1 {
+		ErrorAndExit("must specify a test suite name")
+	}
+	force, _ := ActiveForce()
+	if _, err := force.CreateTestSuite(args[0]); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	if len(args) > 1 {
+		if err := force.AddTestSuiteClasses(args[0], args[1:]); err != nil {
+			ErrorAndExit(err.Error())
+		}
+	}
+	fmt.Printf("Created test suite %s\n", args[0])
+}
+
+func runTestSuiteAdd(args []string) {
+	if len(args) < 2 {
+		ErrorAndExit("must specify a test suite and classes to add")
+	}
+	force, _ := ActiveForce()
+	if err := force.AddTestSuiteClasses(args[0], args[1:]); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	fmt.Printf("Added %s to test suite %s\n", strings.Join(args[1:], ", "), args[0])
+}
+
+func runTestSuiteRemove(args []string) {
+	if len(args) < 2 {
+		ErrorAndExit("must specify a test suite and classes to remove")
+	}
+	force, _ := ActiveForce()
+	if err := force.RemoveTestSuiteClasses(args[0], args[1:]); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	fmt.Printf("Removed %s from test suite %s\n", strings.Join(args[1:], ", "), args[0])
+}
+
+func runTestSuiteDelete(args []string) {
+	if len(args) != 1 {
+		ErrorAndExit("must specify a single test suite")
+	}
+	force, _ := ActiveForce()
+	if err := force.DeleteTestSuite(args[0]); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	fmt.Printf("Deleted test suite %s\n", args[0])
+}
@@ -0,0 +1,167 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ForceCLI/force/config"
+	. "github.com/ForceCLI/force/error"
+	. "github.com/ForceCLI/force/lib"
+	"gopkg.in/fsnotify.v1"
+)
+
+var cmdWatch = &Command{
+	Run:   runWatch,
+	Usage: "watch [-delay <duration>] [<directory>]",
+	Short: "Push files automatically when they are saved",
+	Long: `
+Watch a directory and push files automatically when they are saved
+
+Files saved within the delay of each other are pushed together in a single
+deploy.  Aura and Lightning Web Component files are pushed with the rest of
+their bundle.  Compile errors are displayed with the file and line number, and
+a desktop notification is displayed after each deploy if notifications are
+enabled with "force notify".
+
+The directory defaults to the project's metadata directory.  Deleted files are
+not removed from the org.  Press Ctrl-C to stop watching.
+
+Options
+  -delay  Time to wait for more changes before pushing (default 1s)
+
+Examples:
+
+  force watch
+
+  force watch -delay 3s src
+`,
+	MaxExpectedArgs: 1,
+}
+
+var watchDelay time.Duration
+
+func init() {
+	cmdWatch.Flag.DurationVar(&watchDelay, "delay", time.Second, "time to wait for more changes before pushing")
+}
+
+func runWatch(cmd *Command, args []string) {
+	var root string
+	if len(args) == 1 {
+		root = args[0]
+	} else {
+		var err error
+		root, err = config.GetSourceDir()
+		ExitIfNoSourceDir(err)
+	}
+	force, err := ActiveForce()
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	force.Metadata.CacheMetadataTypes()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		ErrorAndExit(err.Error())
+	}
+	defer watcher.Close()
+	if err = watchDirectory(watcher, root); err != nil {
+		ErrorAndExit(err.Error())
+	}
+	fmt.Printf("Watching %s for changes...\n", root)
+
+	opts := deployOpts()
+	changed := make(map[string]bool)
+	timer := time.NewTimer(watchDelay)
+	timer.Stop()
+	for {
+		select {
+		case event := <-watcher.Events:
+			if ignoredWatchFile(event.Name) || event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+				continue
+			}
+			if f, err := os.Stat(event.Name); err == nil && f.IsDir() {
+				if err := watchDirectory(watcher, event.Name); err != nil {
+					fmt.Fprintln(os.Stderr, err.Error())
+				}
+				continue
+			}
+			changed[event.Name] = true
+			timer.Reset(watchDelay)
+		case err := <-watcher.Errors:
+			fmt.Fprintln(os.Stderr, err.Error())
+		case <-timer.C:
+			var paths []string
+			for p := range changed {
+				paths = append(paths, p)
+			}
+			changed = make(map[string]bool)
+			if err := pushWatchedFiles(WatchedPushPaths(paths), opts); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+			}
+			fmt.Printf("\nWatching %s for changes...\n", root)
+		}
+	}
+}
+
+// Push the changed files, returning errors instead of exiting so watching
+// can continue.  Files that can't be converted from the source format, such
+// as malformed XML, are reported without deploying.
+func pushWatchedFiles(paths []string, opts *ForceDeployOptions) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	fmt.Printf("\n%s Pushing %s\n", time.Now().Format("15:04:05"), strings.Join(paths, ", "))
+	// Metadata types are cached when watching starts.  Deploy results are
+	// displayed and notified by DeployPaths.
+	return DeployPaths(paths, false, make(map[string]string), opts)
+}
+
+// Watch a directory and its subdirectories, except hidden directories
+func watchDirectory(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !f.IsDir() {
+			return nil
+		}
+		if path != root && ignoredWatchFile(path) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// Editor swap and backup files, and hidden files and directories, aren't
+// pushed
+func ignoredWatchFile(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, ".") ||
+		strings.HasPrefix(name, "#") ||
+		strings.HasSuffix(name, "~") ||
+		strings.HasSuffix(name, ".swp") ||
+		name == "4913"
+}
+
+// Get the paths to push for a set of changed files.  Files in Aura and
+// Lightning Web Component bundles are replaced with their bundle directory,
+// and files that no longer exist are skipped.
+func WatchedPushPaths(changed []string) (paths []string) {
+	seen := make(map[string]bool)
+	for _, p := range changed {
+		if ignoredWatchFile(p) || !pathExistsOnDisk(p) {
+			continue
+		}
+		p = replaceComponentWithBundle(p)
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return
+}
@@ -0,0 +1,76 @@
+package command_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/ForceCLI/force/command"
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Watch", func() {
+	Describe("WatchedPushPaths", func() {
+		var root string
+
+		BeforeEach(func() {
+			tempDir, _ := ioutil.TempDir("", "watch-test")
+			root = filepath.Join(tempDir, "src")
+			os.MkdirAll(filepath.Join(root, "classes"), 0755)
+			os.MkdirAll(filepath.Join(root, "lwc", "myComponent"), 0755)
+			for _, name := range []string{
+				"classes/MyClass.cls",
+				"classes/MyClass.cls-meta.xml",
+				"classes/.MyClass.cls.swp",
+				"lwc/myComponent/myComponent.js",
+				"lwc/myComponent/myComponent.html",
+			} {
+				ioutil.WriteFile(filepath.Join(root, name), []byte{}, 0644)
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(filepath.Dir(root))
+		})
+
+		It("should group bundle files and skip ignored and deleted files", func() {
+			paths := WatchedPushPaths([]string{
+				filepath.Join(root, "lwc", "myComponent", "myComponent.js"),
+				filepath.Join(root, "classes", "MyClass.cls"),
+				filepath.Join(root, "classes", ".MyClass.cls.swp"),
+				filepath.Join(root, "classes", "Deleted.cls"),
+				filepath.Join(root, "lwc", "myComponent", "myComponent.html"),
+				filepath.Join(root, "classes", "MyClass.cls~"),
+			})
+			Expect(paths).To(Equal([]string{
+				filepath.Join(root, "classes", "MyClass.cls"),
+				filepath.Join(root, "lwc", "myComponent"),
+			}))
+		})
+	})
+
+	Describe("PushWatchedFiles", func() {
+		var root string
+
+		BeforeEach(func() {
+			tempDir, _ := ioutil.TempDir("", "watch-test")
+			root = filepath.Join(tempDir, "force-app", "main", "default")
+			os.MkdirAll(filepath.Join(root, "objects", "Book__c", "fields"), 0755)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(filepath.Dir(filepath.Dir(filepath.Dir(root))))
+		})
+
+		It("should return an error for malformed source format files", func() {
+			field := filepath.Join(root, "objects", "Book__c", "fields", "Author__c.field-meta.xml")
+			ioutil.WriteFile(field, []byte("<CustomField>"), 0644)
+			err := PushWatchedFiles([]string{field}, &ForceDeployOptions{})
+			Expect(err).To(MatchError(ContainSubstring("Could not parse")))
+			Expect(err).To(MatchError(ContainSubstring("Author__c.field-meta.xml")))
+		})
+	})
+})
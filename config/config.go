@@ -12,6 +12,7 @@ var Config = config.NewConfig("force")
 var sourceDirs = []string{
 	"src",
 	"metadata",
+	filepath.Join("force-app", "main", "default"),
 }
 
 // IsSourceDir returns a boolean indicating that dir is actually a Salesforce
@@ -0,0 +1,252 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Asynchronous Apex test runs, enqueued through the Tooling API's
+// runTestsAsynchronous resource.  A run is an AsyncApexJob with an
+// ApexTestQueueItem for each class and an ApexTestResult for each method.
+
+type AsyncTestResult struct {
+	Id         string
+	ClassName  string
+	MethodName string
+	Outcome    string
+	Message    string
+	StackTrace string
+	RunTime    float64
+}
+
+type AsyncTestQueueItem struct {
+	Id             string
+	ClassName      string
+	Status         string
+	ExtendedStatus string
+}
+
+type asyncTestNode struct {
+	ClassName   string   `json:"className"`
+	TestMethods []string `json:"testMethods,omitempty"`
+}
+
+type asyncTestsRequest struct {
+	ClassNames string          `json:"classNames,omitempty"`
+	Tests      []asyncTestNode `json:"tests,omitempty"`
+	TestLevel  string          `json:"testLevel,omitempty"`
+}
+
+// Build the runTestsAsynchronous request for tests given as for RunTests:
+// "all", class names, or methods within a class
+func newAsyncTestsRequest(tests []string) (request asyncTestsRequest) {
+	if len(tests) == 0 || (len(tests) == 1 && strings.EqualFold(tests[0], "all")) {
+		request.TestLevel = "RunAllTestsInOrg"
+		return
+	}
+	methods := make(map[string][]string)
+	var classes []string
+	for _, test := range tests {
+		class, method := splitClassMethod(test)
+		if _, seen := methods[class]; !seen {
+			classes = append(classes, class)
+			methods[class] = nil
+		}
+		if method != "" {
+			methods[class] = append(methods[class], method)
+		}
+	}
+	containsMethods := false
+	for _, class := range classes {
+		if len(methods[class]) > 0 {
+			containsMethods = true
+		}
+	}
+	if !containsMethods {
+		request.ClassNames = strings.Join(classes, ",")
+		return
+	}
+	for _, class := range classes {
+		request.Tests = append(request.Tests, asyncTestNode{ClassName: class, TestMethods: methods[class]})
+	}
+	return
+}
+
+// Enqueue tests to run asynchronously, returning the id of the AsyncApexJob
+func (f *Force) RunTestsAsynchronous(tests []string) (jobId string, err error) {
+	data, err := json.Marshal(newAsyncTestsRequest(tests))
+	if err != nil {
+		return
+	}
+	body, err := f.PostREST("tooling/runTestsAsynchronous", string(data))
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal([]byte(body), &jobId); err != nil {
+		err = fmt.Errorf("Unexpected response enqueueing tests: %s", body)
+	}
+	return
+}
+
+var jobIdPattern = regexp.MustCompile(`^[a-zA-Z0-9]{15}([a-zA-Z0-9]{3})?$`)
+
+// Check that a job id is a 15 or 18 character Salesforce id before using it
+// in a query
+func checkJobId(jobId string) error {
+	if !jobIdPattern.MatchString(jobId) {
+		return fmt.Errorf("Invalid job id %q: expected a 15 or 18 character Salesforce id", jobId)
+	}
+	return nil
+}
+
+// Get the queued classes of an asynchronous test run
+func (f *Force) AsyncTestQueueItems(jobId string) (items []AsyncTestQueueItem, err error) {
+	if err = checkJobId(jobId); err != nil {
+		return
+	}
+	result, err := f.Query(fmt.Sprintf("SELECT Id, ApexClass.Name, Status, ExtendedStatus FROM ApexTestQueueItem WHERE ParentJobId = '%s'", jobId))
+	if err != nil {
+		return
+	}
+	for _, record := range result.Records {
+		item := AsyncTestQueueItem{ClassName: relatedField(record, "ApexClass", "Name")}
+		item.Id, _ = record["Id"].(string)
+		item.Status, _ = record["Status"].(string)
+		item.ExtendedStatus, _ = record["ExtendedStatus"].(string)
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		err = fmt.Errorf("No test run found with job id %s", jobId)
+	}
+	return
+}
+
+// Get the method results of an asynchronous test run so far
+func (f *Force) AsyncTestResults(jobId string) (results []AsyncTestResult, err error) {
+	if err = checkJobId(jobId); err != nil {
+		return
+	}
+	result, err := f.Query(fmt.Sprintf("SELECT Id, ApexClass.Name, MethodName, Outcome, Message, StackTrace, RunTime FROM ApexTestResult WHERE AsyncApexJobId = '%s' ORDER BY TestTimestamp", jobId))
+	if err != nil {
+		return
+	}
+	for _, record := range result.Records {
+		results = append(results, asyncTestResultFromRecord(record))
+	}
+	return
+}
+
+func asyncTestResultFromRecord(record ForceRecord) (r AsyncTestResult) {
+	r.Id, _ = record["Id"].(string)
+	r.ClassName = relatedField(record, "ApexClass", "Name")
+	r.MethodName, _ = record["MethodName"].(string)
+	r.Outcome, _ = record["Outcome"].(string)
+	r.Message, _ = record["Message"].(string)
+	r.StackTrace, _ = record["StackTrace"].(string)
+	r.RunTime, _ = record["RunTime"].(float64)
+	return
+}
+
+func (item AsyncTestQueueItem) Finished() bool {
+	switch item.Status {
+	case "Completed", "Failed", "Aborted":
+		return true
+	}
+	return false
+}
+
+func asyncTestsFinished(items []AsyncTestQueueItem) bool {
+	for _, item := range items {
+		if !item.Finished() {
+			return false
+		}
+	}
+	return true
+}
+
+// Wait for an asynchronous test run to finish, calling onResult with each
+// method result as it is reported.  Results already reported when waiting
+// starts are included, so a run can be resumed by waiting on its job id.
+func (f *Force) WaitForAsyncTests(jobId string, interval time.Duration, onResult func(AsyncTestResult)) (results []AsyncTestResult, items []AsyncTestQueueItem, err error) {
+	seen := make(map[string]bool)
+	report := func() error {
+		current, err := f.AsyncTestResults(jobId)
+		if err != nil {
+			return err
+		}
+		for _, r := range current {
+			if seen[r.Id] {
+				continue
+			}
+			seen[r.Id] = true
+			results = append(results, r)
+			if onResult != nil {
+				onResult(r)
+			}
+		}
+		return nil
+	}
+	for {
+		if items, err = f.AsyncTestQueueItems(jobId); err != nil {
+			return
+		}
+		finished := asyncTestsFinished(items)
+		// Results are fetched after checking the queue so none reported
+		// before the run finished are missed
+		if err = report(); err != nil || finished {
+			return
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Abort an asynchronous test run.  Classes that have already finished are
+// left alone.
+func (f *Force) AbortAsyncTests(jobId string) (aborted int, err error) {
+	items, err := f.AsyncTestQueueItems(jobId)
+	if err != nil {
+		return
+	}
+	for _, item := range items {
+		if item.Finished() {
+			continue
+		}
+		if err = f.UpdateRecord("ApexTestQueueItem", item.Id, map[string]string{"Status": "Aborted"}); err != nil {
+			return
+		}
+		aborted++
+	}
+	if aborted == 0 {
+		err = errors.New("Test run has already finished")
+	}
+	return
+}
+
+// Convert the results of an asynchronous test run to the results of a
+// synchronous run so they can be displayed and reported the same way
+func AsyncTestCoverage(results []AsyncTestResult) (output TestCoverage) {
+	for _, r := range results {
+		output.NumberRun++
+		if r.Passed() {
+			output.SClassNames = append(output.SClassNames, r.ClassName)
+			output.SMethodNames = append(output.SMethodNames, r.MethodName)
+			output.STime = append(output.STime, r.RunTime)
+			continue
+		}
+		output.NumberFailures++
+		output.FClassNames = append(output.FClassNames, r.ClassName)
+		output.FMethodNames = append(output.FMethodNames, r.MethodName)
+		output.FMessage = append(output.FMessage, r.Message)
+		output.FStackTrace = append(output.FStackTrace, r.StackTrace)
+		output.FTime = append(output.FTime, r.RunTime)
+	}
+	return
+}
+
+func (r AsyncTestResult) Passed() bool {
+	return r.Outcome == "Pass" || r.Outcome == "Skip"
+}
@@ -0,0 +1,69 @@
+package lib_test
+
+import (
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Asynctest", func() {
+	Describe("NewAsyncTestsRequest", func() {
+		It("should run all tests in the org", func() {
+			request := NewAsyncTestsRequest([]string{"all"})
+			Expect(request.TestLevel).To(Equal("RunAllTestsInOrg"))
+			Expect(request.ClassNames).To(BeEmpty())
+		})
+		It("should list classes by name", func() {
+			request := NewAsyncTestsRequest([]string{"Test1", "Test2"})
+			Expect(request.ClassNames).To(Equal("Test1,Test2"))
+			Expect(request.Tests).To(BeEmpty())
+		})
+		It("should group test methods by class", func() {
+			request := NewAsyncTestsRequest([]string{"Test1.method1", "Test2", "Test1.method2"})
+			Expect(request.ClassNames).To(BeEmpty())
+			Expect(request.Tests).To(HaveLen(2))
+			Expect(request.Tests[0].ClassName).To(Equal("Test1"))
+			Expect(request.Tests[0].TestMethods).To(Equal([]string{"method1", "method2"}))
+			Expect(request.Tests[1].ClassName).To(Equal("Test2"))
+			Expect(request.Tests[1].TestMethods).To(BeEmpty())
+		})
+	})
+
+	Describe("AsyncTestCoverage", func() {
+		It("should convert the results of the run", func() {
+			output := AsyncTestCoverage([]AsyncTestResult{
+				{ClassName: "Test1", MethodName: "passes", Outcome: "Pass", RunTime: 120},
+				{ClassName: "Test1", MethodName: "fails", Outcome: "Fail", Message: "Assertion Failed", StackTrace: "Class.Test1.fails: line 5", RunTime: 80},
+				{ClassName: "Test2", MethodName: "broken", Outcome: "CompileFail", Message: "Compile error"},
+			})
+			Expect(output.NumberRun).To(Equal(3))
+			Expect(output.NumberFailures).To(Equal(2))
+			Expect(output.SMethodNames).To(Equal([]string{"passes"}))
+			Expect(output.FMethodNames).To(Equal([]string{"fails", "broken"}))
+			Expect(output.STime).To(Equal([]float64{120}))
+			Expect(output.FTime).To(Equal([]float64{80, 0}))
+		})
+	})
+
+	Describe("AsyncTestQueueItem", func() {
+		It("should be finished once completed, failed, or aborted", func() {
+			for _, status := range []string{"Completed", "Failed", "Aborted"} {
+				Expect(AsyncTestQueueItem{Status: status}.Finished()).To(BeTrue())
+			}
+			Expect(AsyncTestQueueItem{Status: "Processing"}.Finished()).To(BeFalse())
+		})
+	})
+
+	Describe("AsyncTestResults", func() {
+		It("should reject job ids that aren't Salesforce ids", func() {
+			force := NewForce(&ForceSession{InstanceUrl: "https://example.my.salesforce.com"})
+			for _, jobId := range []string{"", "7070000000000010' OR Id != '", "707000000000001AA"} {
+				_, err := force.AsyncTestResults(jobId)
+				Expect(err).To(MatchError(HavePrefix("Invalid job id")), jobId)
+				_, err = force.AsyncTestQueueItems(jobId)
+				Expect(err).To(MatchError(HavePrefix("Invalid job id")), jobId)
+			}
+		})
+	})
+})
@@ -15,8 +15,34 @@ import (
 // Creates a package that includes everything in the passed in string slice
 // and then deploys the package to salesforce
 func PushByPaths(fpaths []string, byName bool, namePaths map[string]string, opts *ForceDeployOptions) {
+	if err := PushPaths(fpaths, byName, namePaths, opts); err != nil {
+		ErrorAndExit(err.Error())
+	}
+}
+
+// Deploy a package that includes the files and directories, returning an
+// error instead of exiting if they could not be deployed
+func PushPaths(fpaths []string, byName bool, namePaths map[string]string, opts *ForceDeployOptions) error {
+	if force, err := ActiveForce(); err == nil {
+		force.Metadata.CacheMetadataTypes()
+	}
+	return DeployPaths(fpaths, byName, namePaths, opts)
+}
+
+// Deploy a package that includes the files and directories without caching
+// the org's metadata types first, for callers that have already cached them
+func DeployPaths(fpaths []string, byName bool, namePaths map[string]string, opts *ForceDeployOptions) error {
 	pb := NewPushBuilder()
-	var badPaths []string
+	badPaths := pb.AddPaths(fpaths, namePaths)
+	if len(badPaths) > 0 {
+		return fmt.Errorf("Could not add the following files:\n {%v}", strings.Join(badPaths, "\n"))
+	}
+	return pushPackage(&pb, byName, namePaths, opts)
+}
+
+// Add files and directories to the package, storing paths by name for error
+// messages.  Paths that could not be added are returned.
+func (pb *PackageBuilder) AddPaths(fpaths []string, namePaths map[string]string) (badPaths []string) {
 	for _, fpath := range fpaths {
 
 		fi, err := os.Stat(fpath)
@@ -29,7 +55,7 @@ func PushByPaths(fpaths []string, byName bool, namePaths map[string]string, opts
 		mode := fi.Mode()
 		//If path provided is dir we are adding all containing files to deployment
 		if mode.IsDir() {
-			dirNamePaths, dirBadPath, err := pb.AddDirectory(fpath)
+			dirNamePaths, dirBadPath, err := pb.AddDirectory(fpath, AddDirectoryOptions{})
 			if err != nil {
 				Log.Info(err.Error())
 				badPaths = append(badPaths, dirBadPath...)
@@ -49,32 +75,144 @@ func PushByPaths(fpaths []string, byName bool, namePaths map[string]string, opts
 			}
 		}
 	}
+	return
+}
 
-	if len(badPaths) == 0 {
-		Log.Info("Deploying now...")
-		t0 := time.Now()
-		deployFiles(pb.ForceMetadataFiles(), byName, namePaths, opts)
-		t1 := time.Now()
-		Log.Info(fmt.Sprintf("The deployment took %v to run.\n", t1.Sub(t0)))
-	} else {
-		ErrorAndExit("Could not add the following files:\n {%v}", strings.Join(badPaths, "\n"))
+// Deploy a package that has been built, or display it if the deploy options
+// request a dry run
+func PushPackage(pb *PackageBuilder, byName bool, namePaths map[string]string, opts *ForceDeployOptions) {
+	if err := pushPackage(pb, byName, namePaths, opts); err != nil {
+		ErrorAndExit(err.Error())
 	}
 }
 
-func deployFiles(files ForceMetadataFiles, byName bool, namePaths map[string]string, opts *ForceDeployOptions) {
+func pushPackage(pb *PackageBuilder, byName bool, namePaths map[string]string, opts *ForceDeployOptions) (err error) {
+	if opts.DryRun && opts.Split {
+		files, err := pb.ForceMetadataFiles()
+		if err != nil {
+			return err
+		}
+		return displayDeployWaves(files)
+	}
+	if opts.DryRun {
+		fmt.Println(string(pb.PackageXml()))
+		if len(pb.DestructiveChanges) > 0 {
+			fmt.Println()
+			fmt.Println(string(pb.DestructiveChangesXml()))
+		}
+		return
+	}
+	files, err := pb.ForceMetadataFiles()
+	if err != nil {
+		return
+	}
+	Log.Info("Deploying now...")
+	t0 := time.Now()
+	err = deployFiles(files, byName, namePaths, opts)
+	t1 := time.Now()
+	Log.Info(fmt.Sprintf("The deployment took %v to run.\n", t1.Sub(t0)))
+	return
+}
+
+// Display the package.xml of each wave of a split deploy
+func displayDeployWaves(files ForceMetadataFiles) (err error) {
+	waves, err := SplitDeployWaves(files)
+	if err != nil {
+		return
+	}
+	for i, wave := range waves {
+		runsTests := ""
+		if wave.RunTests {
+			runsTests = " (runs tests)"
+		}
+		fmt.Printf("Wave %d of %d: %s%s\n", i+1, len(waves), wave.Name, runsTests)
+		fmt.Println(string(wave.Files["package.xml"]))
+	}
+	return
+}
+
+func deployFiles(files ForceMetadataFiles, byName bool, namePaths map[string]string, opts *ForceDeployOptions) (err error) {
+	if err = SnapshotBeforeDeploy(files, opts); err != nil {
+		return
+	}
+	if len(opts.To) > 0 {
+		var results []OrgDeployResult
+		if results, err = DeployToOrgs(opts.To, files, *opts, opts.Workers, opts.StopOnFailure); err != nil {
+			return
+		}
+		return DisplayOrgDeployResults(results)
+	}
+	if opts.Split {
+		return DeployInWaves(files, byName, namePaths, opts)
+	}
 	force, _ := ActiveForce()
+	if opts.Async {
+		var id string
+		if id, err = force.Metadata.StartDeploy(files, *opts); err != nil {
+			return
+		}
+		DisplayDeployStarted(id, nil)
+		return
+	}
 	result, err := force.Metadata.Deploy(files, *opts)
-	err = processDeployResults(result, byName, namePaths, err)
+	if err != nil {
+		return
+	}
+	if err = writeDeployReport(result, opts); err != nil {
+		return
+	}
+	return ProcessDeployResults(result, byName, namePaths, nil)
+}
+
+// Deploy files in waves that are deployed one after another in dependency
+// order, displaying the results of each wave.  Deploying stops at the first
+// wave that fails.  The requested tests are only run in one wave; see
+// SplitDeployWaves.
+func DeployInWaves(files ForceMetadataFiles, byName bool, namePaths map[string]string, opts *ForceDeployOptions) (err error) {
+	waves, err := SplitDeployWaves(files)
+	if err != nil {
+		return
+	}
+	force, _ := ActiveForce()
+	for i, wave := range waves {
+		fmt.Printf("\nDeploying wave %d of %d: %s (%d files)\n", i+1, len(waves), wave.Name, len(wave.Files)-1)
+		var result ForceCheckDeploymentStatusResult
+		if result, err = force.Metadata.Deploy(wave.Files, wave.DeployOptions(*opts)); err != nil {
+			return fmt.Errorf("Wave %d (%s) failed: %s", i+1, wave.Name, err.Error())
+		}
+		if err = ProcessDeployResults(result, byName, namePaths, nil); err != nil {
+			return fmt.Errorf("Wave %d (%s) failed: %s", i+1, wave.Name, err.Error())
+		}
+	}
+	fmt.Printf("\nDeployed %d waves\n", len(waves))
+	return
+}
+
+// Display the id of a deploy started without waiting for it to finish
+func DisplayDeployStarted(id string, err error) {
 	if err != nil {
 		ErrorAndExit(err.Error())
 	}
+	fmt.Printf("Deploy Id %s\n", id)
+	fmt.Printf("Check its status with: force deploy status %s\n", id)
+}
+
+// Write the reports requested in the deploy options
+func writeDeployReport(result ForceCheckDeploymentStatusResult, opts *ForceDeployOptions) (err error) {
+	if opts.Report == "" {
+		return
+	}
+	if err = NewDeployReport(result).Write(opts.Report); err != nil {
+		err = fmt.Errorf("Could not write report: %s", err.Error())
+	}
 	return
 }
 
-// Process and display the result of the push operation
-func processDeployResults(result ForceCheckDeploymentStatusResult, byName bool, namePaths map[string]string, deployErr error) (err error) {
+// Process and display the result of the push operation.  If the deploy
+// failed with an error, the error is returned.
+func ProcessDeployResults(result ForceCheckDeploymentStatusResult, byName bool, namePaths map[string]string, deployErr error) (err error) {
 	if deployErr != nil {
-		ErrorAndExit(deployErr.Error())
+		return deployErr
 	}
 
 	problems := result.Details.ComponentFailures
@@ -156,10 +294,18 @@ func DeployPackage(resourcepaths []string, opts *ForceDeployOptions) {
 	force, _ := ActiveForce()
 	for _, name := range resourcepaths {
 		zipfile, err := ioutil.ReadFile(name)
+		if opts.Async {
+			id, err := force.Metadata.StartDeployZipFile(force.Metadata.MakeDeploySoap(*opts), zipfile)
+			DisplayDeployStarted(id, err)
+			continue
+		}
 		result, err := force.Metadata.DeployZipFile(force.Metadata.MakeDeploySoap(*opts), zipfile)
+		if err == nil {
+			err = writeDeployReport(result, opts)
+		}
 		byName := false
 		namePaths := make(map[string]string)
-		err = processDeployResults(result, byName, namePaths, err)
+		err = ProcessDeployResults(result, byName, namePaths, err)
 		if err != nil {
 			ErrorAndExit(err.Error())
 		}
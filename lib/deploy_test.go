@@ -0,0 +1,45 @@
+package lib_test
+
+import (
+	"encoding/xml"
+	"errors"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deploy", func() {
+	// Deploys from watch must return errors rather than exiting
+	Describe("WriteDeployReport", func() {
+		It("should return an error if the report can't be written", func() {
+			err := WriteDeployReport(ForceCheckDeploymentStatusResult{}, &ForceDeployOptions{Report: "junit=/no-such-dir/results.xml"})
+			Expect(err).To(MatchError(HavePrefix("Could not write report")))
+		})
+	})
+
+	Describe("ProcessDeployResults", func() {
+		It("should return the deploy error", func() {
+			deployErr := errors.New("deploy failed")
+			err := ProcessDeployResults(ForceCheckDeploymentStatusResult{}, false, nil, deployErr)
+			Expect(err).To(Equal(deployErr))
+		})
+	})
+
+	Describe("CancelDeploy", func() {
+		It("should send the deploy id in the metadata namespace", func() {
+			soap := NewSoap("https://example.my.salesforce.com", "http://soap.sforce.com/2006/04/metadata", "token")
+			var envelope struct {
+				Action struct {
+					XMLName xml.Name
+					Id      string `xml:"String"`
+				} `xml:"Body>cancelDeploy"`
+			}
+			err := xml.Unmarshal([]byte(soap.Envelope("cancelDeploy", CancelDeployXml("0Af1200000FFbBzCAL"))), &envelope)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(envelope.Action.XMLName.Space).To(Equal("http://soap.sforce.com/2006/04/metadata"))
+			Expect(envelope.Action.Id).To(Equal("0Af1200000FFbBzCAL"))
+		})
+	})
+})
@@ -0,0 +1,100 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	. "github.com/ForceCLI/force/config"
+)
+
+// Hashes of the files last deployed to each org, so unchanged files can be
+// skipped on the next deploy.  The hashes are kept in the config directory,
+// keyed by org id, and updated after each successful deploy.
+
+// File hashes keyed by the file's path within the deployed package
+type DeployHashes map[string]string
+
+func HashMetadataFile(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Load the hashes of the files last deployed to an org
+func LoadDeployHashes(orgId string) (hashes DeployHashes, err error) {
+	hashes = make(DeployHashes)
+	data, err := Config.Load("deployhashes", orgId)
+	if err != nil {
+		// Nothing has been deployed to the org yet
+		err = nil
+		return
+	}
+	err = json.Unmarshal([]byte(data), &hashes)
+	return
+}
+
+// Save the hashes of the files deployed to an org
+func (hashes DeployHashes) Save(orgId string) (err error) {
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return
+	}
+	return Config.Save("deployhashes", orgId, string(data))
+}
+
+// Get the names of the files that have changed since they were last
+// deployed.  Package manifests are ignored.
+func (hashes DeployHashes) Changed(files ForceMetadataFiles) (changed []string) {
+	for _, name := range sortedFileNames(files) {
+		if isPackageManifest(name) {
+			continue
+		}
+		if hashes[name] != HashMetadataFile(files[name]) {
+			changed = append(changed, name)
+		}
+	}
+	return
+}
+
+// Record the hashes of deployed files
+func (hashes DeployHashes) Update(files ForceMetadataFiles) {
+	for name, data := range files {
+		if !isPackageManifest(name) {
+			hashes[name] = HashMetadataFile(data)
+		}
+	}
+}
+
+// Get the id of the org the metadata is deployed to
+func (fm *ForceMetadata) orgId() (orgId string, err error) {
+	if fm.Force.Credentials == nil || fm.Force.Credentials.UserInfo == nil || fm.Force.Credentials.UserInfo.OrgId == "" {
+		err = errors.New("Org id not known for the active session")
+		return
+	}
+	orgId = fm.Force.Credentials.UserInfo.OrgId
+	return
+}
+
+// Load the hashes of the files last deployed to the org
+func (fm *ForceMetadata) DeployHashes() (hashes DeployHashes, err error) {
+	orgId, err := fm.orgId()
+	if err != nil {
+		return
+	}
+	return LoadDeployHashes(orgId)
+}
+
+// Record the files of a successful deploy in the org's deploy hashes
+func (fm *ForceMetadata) recordDeployedFiles(files ForceMetadataFiles) (err error) {
+	orgId, err := fm.orgId()
+	if err != nil {
+		return
+	}
+	hashes, err := LoadDeployHashes(orgId)
+	if err != nil {
+		return
+	}
+	hashes.Update(files)
+	return hashes.Save(orgId)
+}
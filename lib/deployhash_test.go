@@ -0,0 +1,39 @@
+package lib_test
+
+import (
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeployHashes", func() {
+	var hashes DeployHashes
+
+	BeforeEach(func() {
+		hashes = make(DeployHashes)
+		hashes.Update(ForceMetadataFiles{
+			"package.xml":                []byte("<Package/>"),
+			"classes/A.cls":              []byte("public class A {}"),
+			"classes/A.cls-meta.xml":     []byte("<ApexClass/>"),
+			"classes/B.cls":              []byte("public class B {}"),
+			"destructiveChangesPost.xml": []byte("<Package/>"),
+		})
+	})
+
+	It("should not record package manifests", func() {
+		Expect(hashes).ToNot(HaveKey("package.xml"))
+		Expect(hashes).ToNot(HaveKey("destructiveChangesPost.xml"))
+	})
+
+	It("should list the files that changed since the last deploy", func() {
+		changed := hashes.Changed(ForceMetadataFiles{
+			"package.xml":            []byte("<Package><types/></Package>"),
+			"classes/A.cls":          []byte("public class A {}"),
+			"classes/A.cls-meta.xml": []byte("<ApexClass><status>Active</status></ApexClass>"),
+			"classes/B.cls":          []byte("public class B { }"),
+			"classes/C.cls":          []byte("public class C {}"),
+		})
+		Expect(changed).To(Equal([]string{"classes/A.cls-meta.xml", "classes/B.cls", "classes/C.cls"}))
+	})
+})
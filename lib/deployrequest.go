@@ -0,0 +1,124 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Recent deploys and validations, from the DeployRequest Tooling API object
+
+type DeployRequest struct {
+	Id              string
+	Status          string
+	CheckOnly       bool
+	TestLevel       string
+	RunTestsEnabled bool
+	StartDate       time.Time
+	CompletedDate   time.Time
+	CreatedBy       string
+}
+
+const deployRequestDateLayout = "2006-01-02T15:04:05.000-0700"
+
+// Validations can be quick deployed for 10 days after they complete
+const quickDeployWindow = 10 * 24 * time.Hour
+
+const deployRequestFields = "Id, Status, CheckOnly, TestLevel, RunTestsEnabled, StartDate, CompletedDate, CreatedBy.Name"
+
+// List the most recent deploys and validations, newest first
+func (f *Force) ListDeployRequests(limit int) (requests []DeployRequest, err error) {
+	return f.queryDeployRequests("", limit)
+}
+
+func (f *Force) queryDeployRequests(where string, limit int) (requests []DeployRequest, err error) {
+	soql := fmt.Sprintf("SELECT %s FROM DeployRequest %s ORDER BY CreatedDate DESC LIMIT %d", deployRequestFields, where, limit)
+	result, err := f.toolingQuery(soql)
+	if err != nil && strings.Contains(err.Error(), "TestLevel") {
+		// Older API versions don't have the TestLevel field
+		soql = strings.Replace(soql, " TestLevel,", "", 1)
+		result, err = f.toolingQuery(soql)
+	}
+	if err != nil {
+		return
+	}
+	for _, record := range result.Records {
+		requests = append(requests, deployRequestFromRecord(record))
+	}
+	return
+}
+
+func deployRequestFromRecord(record ForceRecord) (r DeployRequest) {
+	r.Id, _ = record["Id"].(string)
+	r.Status, _ = record["Status"].(string)
+	r.CheckOnly, _ = record["CheckOnly"].(bool)
+	r.TestLevel, _ = record["TestLevel"].(string)
+	r.RunTestsEnabled, _ = record["RunTestsEnabled"].(bool)
+	if s, ok := record["StartDate"].(string); ok {
+		r.StartDate, _ = time.Parse(deployRequestDateLayout, s)
+	}
+	if s, ok := record["CompletedDate"].(string); ok {
+		r.CompletedDate, _ = time.Parse(deployRequestDateLayout, s)
+	}
+	if createdBy, ok := record["CreatedBy"].(map[string]interface{}); ok {
+		r.CreatedBy, _ = createdBy["Name"].(string)
+	}
+	return
+}
+
+// Determine whether a deploy is a validation that can still be quick
+// deployed: a successful check-only deploy that ran tests and completed
+// within the last 10 days
+func (r DeployRequest) QuickDeployable(now time.Time) bool {
+	ranTests := r.RunTestsEnabled || (r.TestLevel != "" && r.TestLevel != "NoTestRun")
+	return r.CheckOnly && r.Status == "Succeeded" && ranTests &&
+		!r.CompletedDate.IsZero() && now.Sub(r.CompletedDate) < quickDeployWindow
+}
+
+// Get the most recent validation that can still be quick deployed
+func (f *Force) LatestQuickDeployableValidation() (request DeployRequest, err error) {
+	requests, err := f.queryDeployRequests("WHERE Status = 'Succeeded'", 100)
+	if err != nil {
+		return
+	}
+	return latestQuickDeployable(requests, time.Now())
+}
+
+// Get the ids of the validations that can still be quick deployed.  A
+// successful deploy makes validations that completed before it ineligible.
+func QuickDeployableIds(requests []DeployRequest, now time.Time) (ids map[string]bool) {
+	ids = make(map[string]bool)
+	var lastDeploy time.Time
+	for _, r := range requests {
+		if !r.CheckOnly && r.Status == "Succeeded" && r.CompletedDate.After(lastDeploy) {
+			lastDeploy = r.CompletedDate
+		}
+	}
+	for _, r := range requests {
+		if r.QuickDeployable(now) && r.CompletedDate.After(lastDeploy) {
+			ids[r.Id] = true
+		}
+	}
+	return
+}
+
+// Find the most recent validation that can still be quick deployed
+func latestQuickDeployable(requests []DeployRequest, now time.Time) (request DeployRequest, err error) {
+	eligible := QuickDeployableIds(requests, now)
+	var latest *DeployRequest
+	for i, r := range requests {
+		if !eligible[r.Id] {
+			continue
+		}
+		if latest == nil || r.CompletedDate.After(latest.CompletedDate) {
+			latest = &requests[i]
+		}
+	}
+	if latest == nil {
+		err = errors.New("No validations that can be quick deployed were found")
+		return
+	}
+	request = *latest
+	return
+}
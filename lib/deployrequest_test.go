@@ -0,0 +1,84 @@
+package lib_test
+
+import (
+	"time"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeployRequest", func() {
+	now := time.Date(2019, 4, 20, 0, 0, 0, 0, time.UTC)
+	validation := func(id string, age time.Duration) DeployRequest {
+		return DeployRequest{Id: id, Status: "Succeeded", CheckOnly: true, TestLevel: "RunLocalTests", CompletedDate: now.Add(-age)}
+	}
+
+	Describe("DeployRequestFromRecord", func() {
+		It("should read the deploy request fields", func() {
+			r := DeployRequestFromRecord(ForceRecord{
+				"Id":              "0Af000000000001CAA",
+				"Status":          "Succeeded",
+				"CheckOnly":       true,
+				"TestLevel":       "RunLocalTests",
+				"RunTestsEnabled": true,
+				"StartDate":       "2019-04-15T10:30:12.000+0000",
+				"CompletedDate":   "2019-04-15T10:35:40.000+0000",
+				"CreatedBy":       map[string]interface{}{"Name": "Admin User"},
+			})
+			Expect(r.Id).To(Equal("0Af000000000001CAA"))
+			Expect(r.Status).To(Equal("Succeeded"))
+			Expect(r.CheckOnly).To(BeTrue())
+			Expect(r.TestLevel).To(Equal("RunLocalTests"))
+			Expect(r.CreatedBy).To(Equal("Admin User"))
+			Expect(r.CompletedDate.Equal(time.Date(2019, 4, 15, 10, 35, 40, 0, time.UTC))).To(BeTrue())
+		})
+		It("should leave missing values empty", func() {
+			r := DeployRequestFromRecord(ForceRecord{"Id": "0Af000000000002CAA", "Status": "InProgress", "CompletedDate": nil, "CreatedBy": nil})
+			Expect(r.CompletedDate.IsZero()).To(BeTrue())
+			Expect(r.CreatedBy).To(BeEmpty())
+		})
+	})
+
+	Describe("QuickDeployable", func() {
+		It("should allow recent validations that ran tests", func() {
+			Expect(validation("recent", 48*time.Hour).QuickDeployable(now)).To(BeTrue())
+		})
+		It("should not allow other deploys", func() {
+			completed := now.Add(-48 * time.Hour)
+			for _, r := range []DeployRequest{
+				{Status: "Succeeded", TestLevel: "RunLocalTests", CompletedDate: completed},
+				{Status: "Failed", CheckOnly: true, TestLevel: "RunLocalTests", CompletedDate: completed},
+				{Status: "Succeeded", CheckOnly: true, TestLevel: "NoTestRun", CompletedDate: completed},
+				{Status: "Succeeded", CheckOnly: true, TestLevel: "RunLocalTests", CompletedDate: now.Add(-11 * 24 * time.Hour)},
+				{Status: "Succeeded", CheckOnly: true, TestLevel: "RunLocalTests"},
+			} {
+				Expect(r.QuickDeployable(now)).To(BeFalse(), "%+v", r)
+			}
+		})
+	})
+
+	Describe("QuickDeployableIds", func() {
+		It("should exclude validations completed before a later deploy", func() {
+			deploy := DeployRequest{Id: "deploy", Status: "Succeeded", TestLevel: "NoTestRun", CompletedDate: now.Add(-24 * time.Hour)}
+			failed := DeployRequest{Id: "failed", Status: "Failed", TestLevel: "NoTestRun", CompletedDate: now.Add(-6 * time.Hour)}
+			ids := QuickDeployableIds([]DeployRequest{validation("newer", 12*time.Hour), failed, deploy, validation("older", 48*time.Hour)}, now)
+			Expect(ids).To(Equal(map[string]bool{"newer": true}))
+		})
+	})
+
+	Describe("LatestQuickDeployable", func() {
+		deploy := DeployRequest{Id: "deploy", Status: "Succeeded", TestLevel: "NoTestRun", CompletedDate: now.Add(-24 * time.Hour)}
+
+		It("should return the newest validation", func() {
+			r, err := LatestQuickDeployable([]DeployRequest{validation("newer", 12*time.Hour), deploy, validation("older", 48*time.Hour)}, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.Id).To(Equal("newer"))
+		})
+		It("should skip validations completed before a later deploy", func() {
+			_, err := LatestQuickDeployable([]DeployRequest{deploy, validation("older", 48*time.Hour)}, now)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
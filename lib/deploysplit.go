@@ -0,0 +1,240 @@
+package lib
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Limits on the size of a Metadata API deploy.  Deploys that exceed them are
+// rejected before they are uploaded, and can be split into smaller deploys
+// that are made in dependency order.
+
+const (
+	// Maximum size of the base64-encoded zip file
+	MaxDeployEncodedSize = 39 * 1024 * 1024
+	// Maximum number of files in the zip file
+	MaxDeployFiles = 10000
+)
+
+type DeploySizeError struct {
+	EncodedSize int
+	Files       int
+}
+
+func (e DeploySizeError) Error() string {
+	var problems []string
+	if e.EncodedSize > MaxDeployEncodedSize {
+		problems = append(problems, fmt.Sprintf("%s encoded (limit %s)", formatMegabytes(e.EncodedSize), formatMegabytes(MaxDeployEncodedSize)))
+	}
+	if e.Files > MaxDeployFiles {
+		problems = append(problems, fmt.Sprintf("%d files (limit %d)", e.Files, MaxDeployFiles))
+	}
+	return fmt.Sprintf("Deploy is too large: %s.  Use -split to deploy in several smaller deploys.", strings.Join(problems, ", "))
+}
+
+func formatMegabytes(bytes int) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+}
+
+// Check that a deploy's zip file is within the Metadata API limits before
+// uploading it
+func checkDeploySize(zipfile []byte) (err error) {
+	reader, err := zip.NewReader(bytes.NewReader(zipfile), int64(len(zipfile)))
+	if err != nil {
+		return
+	}
+	size := DeploySizeError{
+		EncodedSize: base64.StdEncoding.EncodedLen(len(zipfile)),
+		Files:       len(reader.File),
+	}
+	if size.EncodedSize > MaxDeployEncodedSize || size.Files > MaxDeployFiles {
+		err = size
+	}
+	return
+}
+
+// A group of components deployed together when a deploy is split.  Tests
+// are only run in the wave with RunTests set.
+type DeployWave struct {
+	Name     string
+	Files    ForceMetadataFiles
+	RunTests bool
+}
+
+const codeWave = "Code"
+
+// The waves that components are deployed in when a deploy is split, in
+// order.  Components of types not listed are deployed in the "Other
+// metadata" wave.
+var deployWaveTypes = []struct {
+	name  string
+	types []string
+}{
+	{
+		name: "Objects and fields",
+		types: []string{"CustomObject", "CustomField", "GlobalValueSet", "StandardValueSet",
+			"CustomLabels", "CustomPermission", "ExternalDataSource", "NamedCredential", "RemoteSiteSetting"},
+	},
+	{
+		name: codeWave,
+		types: []string{"ApexClass", "ApexTrigger", "ApexPage", "ApexComponent", "AuraDefinitionBundle",
+			"LightningComponentBundle", "StaticResource"},
+	},
+	{
+		name: "Other metadata",
+	},
+	{
+		name: "Layouts and profiles",
+		types: []string{"Layout", "CompactLayout", "FlexiPage", "CustomApplication", "CustomTab",
+			"PermissionSet", "PermissionSetGroup", "Profile"},
+	},
+}
+
+func deployWaveForType(metaName string) int {
+	other := 0
+	for i, wave := range deployWaveTypes {
+		if wave.types == nil {
+			other = i
+		}
+		for _, t := range wave.types {
+			if t == metaName {
+				return i
+			}
+		}
+	}
+	return other
+}
+
+// Split a deploy's files into waves that are deployed in dependency order:
+// objects and fields, code, other metadata, and then layouts and profiles.
+// Waves that are still too large are split into parts without splitting
+// components.  Destructive changes are deployed in the last wave.  Each
+// wave has its own package.xml.  Tests are run in the last part of the code
+// wave, once all the code has been deployed, or in the last wave if there's
+// no code.
+func SplitDeployWaves(files ForceMetadataFiles) (waves []DeployWave, err error) {
+	components := make([]map[string]ForceMetadataFiles, len(deployWaveTypes))
+	for i := range components {
+		components[i] = make(map[string]ForceMetadataFiles)
+	}
+	destructive := make(ForceMetadataFiles)
+	for name, data := range files {
+		if isPackageManifest(name) {
+			if name != "package.xml" {
+				destructive[name] = data
+			}
+			continue
+		}
+		metaName, member := componentForFile(name)
+		if metaName == "" {
+			err = fmt.Errorf("Could not determine the metadata type of %s", name)
+			return
+		}
+		wave := components[deployWaveForType(metaName)]
+		key := componentKey(metaName, member)
+		if wave[key] == nil {
+			wave[key] = make(ForceMetadataFiles)
+		}
+		wave[key][name] = data
+	}
+
+	for i, waveComponents := range components {
+		for _, part := range splitWaveComponents(waveComponents) {
+			waves = append(waves, DeployWave{Name: deployWaveTypes[i].name, Files: part})
+		}
+	}
+	if len(destructive) > 0 {
+		if len(waves) == 0 {
+			waves = append(waves, DeployWave{Name: "Destructive changes", Files: make(ForceMetadataFiles)})
+		}
+		for name, data := range destructive {
+			waves[len(waves)-1].Files[name] = data
+		}
+	}
+	if len(waves) == 0 {
+		err = errors.New("No components to deploy")
+		return
+	}
+
+	testWave := len(waves) - 1
+	for i, wave := range waves {
+		if wave.Name == codeWave {
+			testWave = i
+		}
+	}
+	waves[testWave].RunTests = true
+
+	// Number the parts of waves that had to be split
+	counts := make(map[string]int)
+	for _, wave := range waves {
+		counts[wave.Name]++
+	}
+	seen := make(map[string]int)
+	for i, wave := range waves {
+		if counts[wave.Name] > 1 {
+			seen[wave.Name]++
+			waves[i].Name = fmt.Sprintf("%s (%d/%d)", wave.Name, seen[wave.Name], counts[wave.Name])
+		}
+	}
+	return
+}
+
+// Group a wave's components into parts that are within the deploy limits,
+// adding a package.xml to each part.  The encoded size of each part is
+// estimated from the uncompressed size of its files, so parts are smaller
+// than they need to be.
+func splitWaveComponents(components map[string]ForceMetadataFiles) (parts []ForceMetadataFiles) {
+	var keys []string
+	for key := range components {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var part ForceMetadataFiles
+	var pb PackageBuilder
+	size := 0
+	finishPart := func() {
+		if part != nil {
+			part["package.xml"] = pb.PackageXml()
+			parts = append(parts, part)
+		}
+		part = nil
+	}
+	for _, key := range keys {
+		componentSize := 0
+		for _, data := range components[key] {
+			componentSize += base64.StdEncoding.EncodedLen(len(data))
+		}
+		if part != nil && (size+componentSize > MaxDeployEncodedSize || len(part)+len(components[key]) >= MaxDeployFiles) {
+			finishPart()
+		}
+		if part == nil {
+			part = make(ForceMetadataFiles)
+			pb = NewPushBuilder()
+			size = 0
+		}
+		for name, data := range components[key] {
+			part[name] = data
+		}
+		size += componentSize
+		component := strings.SplitN(key, ":", 2)
+		pb.AddMetaToPackage(component[0], component[1])
+	}
+	finishPart()
+	return
+}
+
+// The deploy options for a wave.  Waves other than the one that runs tests
+// are deployed without running tests.
+func (wave DeployWave) DeployOptions(opts ForceDeployOptions) ForceDeployOptions {
+	if !wave.RunTests {
+		opts.TestLevel = "NoTestRun"
+		opts.RunTests = nil
+	}
+	return opts
+}
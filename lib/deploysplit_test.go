@@ -0,0 +1,135 @@
+package lib_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deploysplit", func() {
+	Describe("SplitDeployWaves", func() {
+		It("should deploy dependencies before the components that use them", func() {
+			waves, err := SplitDeployWaves(ForceMetadataFiles{
+				"package.xml":                         []byte("<Package/>"),
+				"destructiveChangesPost.xml":          []byte("<Package/>"),
+				"objects/Book__c.object":              []byte("<CustomObject/>"),
+				"classes/BookController.cls":          []byte("public class BookController {}"),
+				"classes/BookController.cls-meta.xml": []byte("<ApexClass/>"),
+				"layouts/Book__c-Book Layout.layout":  []byte("<Layout/>"),
+				"profiles/Admin.profile":              []byte("<Profile/>"),
+				"workflows/Book__c.workflow":          []byte("<Workflow/>"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			expected := []struct {
+				name  string
+				files []string
+			}{
+				{"Objects and fields", []string{"objects/Book__c.object"}},
+				{"Code", []string{"classes/BookController.cls", "classes/BookController.cls-meta.xml"}},
+				{"Other metadata", []string{"workflows/Book__c.workflow"}},
+				{"Layouts and profiles", []string{"layouts/Book__c-Book Layout.layout", "profiles/Admin.profile", "destructiveChangesPost.xml"}},
+			}
+			Expect(waves).To(HaveLen(len(expected)))
+			for i, wave := range waves {
+				Expect(wave.Name).To(Equal(expected[i].name))
+				Expect(wave.Files).To(HaveLen(len(expected[i].files) + 1))
+				Expect(wave.Files).To(HaveKey("package.xml"))
+				for _, name := range expected[i].files {
+					Expect(wave.Files).To(HaveKey(name))
+				}
+				Expect(wave.RunTests).To(Equal(i == 1), "tests run in %s", wave.Name)
+			}
+			Expect(string(waves[1].Files["package.xml"])).To(ContainSubstring("<members>BookController</members>"))
+			Expect(string(waves[1].Files["package.xml"])).ToNot(ContainSubstring("Book__c"))
+		})
+
+		It("should run tests in the last wave if there's no code", func() {
+			waves, err := SplitDeployWaves(ForceMetadataFiles{
+				"package.xml":            []byte("<Package/>"),
+				"objects/Book__c.object": []byte("<CustomObject/>"),
+				"profiles/Admin.profile": []byte("<Profile/>"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(waves).To(HaveLen(2))
+			Expect(waves[0].RunTests).To(BeFalse())
+			Expect(waves[1].RunTests).To(BeTrue())
+		})
+
+		It("should name documents with their extension", func() {
+			waves, err := SplitDeployWaves(ForceMetadataFiles{
+				"package.xml":                        []byte("<Package/>"),
+				"documents/Shared/logo.png":          []byte("png"),
+				"documents/Shared/logo.png-meta.xml": []byte("<Document/>"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(waves).To(HaveLen(1))
+			Expect(waves[0].Files).To(HaveLen(3))
+			Expect(string(waves[0].Files["package.xml"])).To(ContainSubstring("<members>Shared/logo.png</members>"))
+		})
+
+		It("should split waves with too many files", func() {
+			files := ForceMetadataFiles{}
+			for i := 0; i < 6000; i++ {
+				name := fmt.Sprintf("classes/Class%d.cls", i)
+				files[name] = []byte("public class X {}")
+				files[name+"-meta.xml"] = []byte("<ApexClass/>")
+			}
+			waves, err := SplitDeployWaves(files)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(waves).To(HaveLen(2))
+			Expect(waves[0].Name).To(Equal("Code (1/2)"))
+			Expect(waves[1].Name).To(Equal("Code (2/2)"))
+			total := 0
+			for _, wave := range waves {
+				Expect(len(wave.Files)).To(BeNumerically("<=", MaxDeployFiles))
+				if _, found := wave.Files["classes/Class0.cls"]; found {
+					Expect(wave.Files).To(HaveKey("classes/Class0.cls-meta.xml"))
+				}
+				total += len(wave.Files) - 1
+			}
+			Expect(total).To(Equal(len(files)))
+		})
+	})
+
+	Describe("DeployOptions", func() {
+		opts := ForceDeployOptions{TestLevel: "RunSpecifiedTests", RunTests: []string{"BookControllerTest"}, CheckOnly: true}
+
+		It("should run no tests in other waves", func() {
+			skipped := DeployWave{Name: "Objects and fields"}.DeployOptions(opts)
+			Expect(skipped.TestLevel).To(Equal("NoTestRun"))
+			Expect(skipped.RunTests).To(BeNil())
+			Expect(skipped.CheckOnly).To(BeTrue())
+		})
+		It("should run the requested tests in the wave that runs tests", func() {
+			tested := DeployWave{Name: "Code", RunTests: true}.DeployOptions(opts)
+			Expect(tested.TestLevel).To(Equal("RunSpecifiedTests"))
+			Expect(tested.RunTests).To(Equal([]string{"BookControllerTest"}))
+		})
+	})
+
+	Describe("CheckDeploySize", func() {
+		makeZip := func(files int) []byte {
+			var b bytes.Buffer
+			zipper := zip.NewWriter(&b)
+			for i := 0; i < files; i++ {
+				zipper.Create(fmt.Sprintf("unpackaged/classes/Class%d.cls", i))
+			}
+			zipper.Close()
+			return b.Bytes()
+		}
+
+		It("should allow deploys within the file limit", func() {
+			Expect(CheckDeploySize(makeZip(10))).To(Succeed())
+		})
+		It("should reject deploys over the file limit", func() {
+			err := CheckDeploySize(makeZip(MaxDeployFiles + 1))
+			Expect(err).To(BeAssignableToTypeOf(DeploySizeError{}))
+			Expect(err.Error()).To(ContainSubstring("10001 files (limit 10000)"))
+		})
+	})
+})
@@ -0,0 +1,326 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Comparison of local metadata with the metadata retrieved from the org.  XML
+// files are normalized before being compared so differences in element order
+// and whitespace are ignored.
+
+type ComponentStatus string
+
+const (
+	ComponentNew       ComponentStatus = "new"
+	ComponentChanged   ComponentStatus = "changed"
+	ComponentUnchanged ComponentStatus = "unchanged"
+	ComponentOnlyInOrg ComponentStatus = "only in org"
+)
+
+type ComponentDiff struct {
+	Type   string
+	Name   string
+	Status ComponentStatus
+	Files  []FileDiff
+}
+
+// A unified diff of a file in a component.  The diff is empty if the file is
+// unchanged.
+type FileDiff struct {
+	Path string
+	Diff string
+}
+
+// Number of unchanged lines shown around each change
+const diffContext = 3
+
+// Compare the local and org versions of a set of metadata files in the
+// metadata format.  Components are sorted by type and name.
+func DiffMetadataFiles(local ForceMetadataFiles, org ForceMetadataFiles) (diffs []ComponentDiff) {
+	components := make(map[string]*ComponentDiff)
+	var keys []string
+	componentFor := func(name string) *ComponentDiff {
+		metaName, member := componentForFile(name)
+		key := metaName + ":" + member
+		if c, found := components[key]; found {
+			return c
+		}
+		c := &ComponentDiff{Type: metaName, Name: member}
+		components[key] = c
+		keys = append(keys, key)
+		return c
+	}
+
+	inLocal := make(map[string]bool)
+	inOrg := make(map[string]bool)
+	for _, name := range sortedFileNames(local) {
+		if isPackageManifest(name) {
+			continue
+		}
+		c := componentFor(name)
+		inLocal[c.Type+":"+c.Name] = true
+		c.Files = append(c.Files, FileDiff{
+			Path: name,
+			Diff: UnifiedDiff("org/"+name, "local/"+name, normalizeForDiff(name, org[name]), normalizeForDiff(name, local[name])),
+		})
+	}
+	for _, name := range sortedFileNames(org) {
+		if isPackageManifest(name) {
+			continue
+		}
+		c := componentFor(name)
+		inOrg[c.Type+":"+c.Name] = true
+		if _, found := local[name]; found {
+			continue
+		}
+		c.Files = append(c.Files, FileDiff{
+			Path: name,
+			Diff: UnifiedDiff("org/"+name, "local/"+name, normalizeForDiff(name, org[name]), nil),
+		})
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		c := components[key]
+		switch {
+		case !inOrg[key]:
+			c.Status = ComponentNew
+		case !inLocal[key]:
+			c.Status = ComponentOnlyInOrg
+		default:
+			c.Status = ComponentUnchanged
+			for _, f := range c.Files {
+				if f.Diff != "" {
+					c.Status = ComponentChanged
+					break
+				}
+			}
+		}
+		diffs = append(diffs, *c)
+	}
+	return
+}
+
+func sortedFileNames(files ForceMetadataFiles) (names []string) {
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// Get the metadata type and name of the component a file in the metadata
+// format belongs to
+func componentForFile(name string) (metaName string, member string) {
+	fpath := filepath.FromSlash(strings.TrimSuffix(name, "-meta.xml"))
+	if filepath.Dir(fpath) == "." {
+		return "", name
+	}
+	metaName, fileName := getMetaForPath(fpath)
+	member = componentMember(metaName, fileName)
+	return
+}
+
+// Normalize a file's contents so insignificant differences aren't reported.
+// Line endings are made consistent, and XML is re-serialized in the
+// canonical form used by "force format", which sorts lists of keyed
+// elements like fields and permissions but keeps the order of elements
+// whose order matters, like picklist values and layout items.
+func normalizeForDiff(name string, data []byte) []byte {
+	if data == nil {
+		return nil
+	}
+	data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+	if path.Ext(name) != ".xml" && !bytes.HasPrefix(bytes.TrimSpace(data), []byte("<?xml")) {
+		return data
+	}
+	root, err := parseXmlNode(data)
+	if err != nil {
+		return data
+	}
+	root.canonicalize()
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	root.write(&b, 0)
+	return b.Bytes()
+}
+
+// Build a unified diff between two versions of a file.  A nil version is
+// treated as a missing file.  The diff is empty if the versions are the same.
+func UnifiedDiff(fromName string, toName string, from []byte, to []byte) string {
+	if bytes.Equal(from, to) && (from == nil) == (to == nil) {
+		return ""
+	}
+	if from == nil {
+		fromName = "/dev/null"
+	}
+	if to == nil {
+		toName = "/dev/null"
+	}
+	a := splitLines(from)
+	b := splitLines(to)
+	ops := diffLines(a, b)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range diffHunks(ops) {
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(h.fromLine, h.fromCount), hunkRange(h.toLine, h.toCount))
+		for _, op := range ops[h.start:h.end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteString("\n")
+		}
+	}
+	return out.String()
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// Compute the edit script between two lists of lines using the longest
+// common subsequence.  Very large changes are reported as a replacement of
+// the changed region to limit memory use.
+func diffLines(a []string, b []string) (ops []diffOp) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ma := a[prefix : len(a)-suffix]
+	mb := b[prefix : len(b)-suffix]
+
+	if len(ma)*len(mb) > 4000000 {
+		for _, line := range ma {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range mb {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of
+		// ma[i:] and mb[j:]
+		lcs := make([][]int, len(ma)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(mb)+1)
+		}
+		for i := len(ma) - 1; i >= 0; i-- {
+			for j := len(mb) - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(ma) || j < len(mb) {
+			switch {
+			case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+				ops = append(ops, diffOp{' ', ma[i]})
+				i++
+				j++
+			case j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, diffOp{'-', ma[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', mb[j]})
+				j++
+			}
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return
+}
+
+type diffHunk struct {
+	start, end          int
+	fromLine, fromCount int
+	toLine, toCount     int
+}
+
+// Group the changes in an edit script into hunks with surrounding context
+func diffHunks(ops []diffOp) (hunks []diffHunk) {
+	fromLine, toLine := 1, 1
+	var h *diffHunk
+	lastChange := -1
+	for i, op := range ops {
+		if op.kind != ' ' {
+			if h == nil || i-lastChange > 2*diffContext {
+				if h != nil {
+					hunks = append(hunks, closeHunk(*h, ops, lastChange))
+				}
+				start := i - diffContext
+				if start < 0 {
+					start = 0
+				}
+				h = &diffHunk{start: start, fromLine: fromLine - (i - start), toLine: toLine - (i - start)}
+			}
+			lastChange = i
+		}
+		switch op.kind {
+		case ' ':
+			fromLine++
+			toLine++
+		case '-':
+			fromLine++
+		case '+':
+			toLine++
+		}
+	}
+	if h != nil {
+		hunks = append(hunks, closeHunk(*h, ops, lastChange))
+	}
+	return
+}
+
+func closeHunk(h diffHunk, ops []diffOp, lastChange int) diffHunk {
+	h.end = lastChange + 1 + diffContext
+	if h.end > len(ops) {
+		h.end = len(ops)
+	}
+	for _, op := range ops[h.start:h.end] {
+		if op.kind != '+' {
+			h.fromCount++
+		}
+		if op.kind != '-' {
+			h.toCount++
+		}
+	}
+	return h
+}
+
+func hunkRange(line int, count int) string {
+	if count == 0 {
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
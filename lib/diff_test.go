@@ -0,0 +1,84 @@
+package lib_test
+
+import (
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diff", func() {
+	Describe("UnifiedDiff", func() {
+		It("should be empty for identical files", func() {
+			Expect(UnifiedDiff("a", "b", []byte("x\ny\n"), []byte("x\ny\n"))).To(BeEmpty())
+		})
+
+		It("should show changed lines with context", func() {
+			from := []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n")
+			to := []byte("1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n")
+			Expect(UnifiedDiff("org/x", "local/x", from, to)).To(Equal(`--- org/x
++++ local/x
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`))
+		})
+
+		It("should show new files", func() {
+			Expect(UnifiedDiff("org/x", "local/x", nil, []byte("a\nb\n"))).To(Equal(`--- /dev/null
++++ local/x
+@@ -0,0 +1,2 @@
++a
++b
+`))
+		})
+	})
+
+	Describe("DiffMetadataFiles", func() {
+		It("should classify components", func() {
+			local := ForceMetadataFiles{
+				"package.xml":              []byte("<Package/>"),
+				"classes/New.cls":          []byte("class New {}"),
+				"classes/New.cls-meta.xml": []byte("<ApexClass/>"),
+				"classes/Changed.cls":      []byte("class Changed { }"),
+				"classes/Same.cls":         []byte("class Same {}\r\n"),
+				"profiles/Admin.profile": []byte("<?xml version=\"1.0\"?>\n<Profile>\n<userPermissions><enabled>true</enabled><name>A</name></userPermissions>\n" +
+					"  <userPermissions><enabled>true</enabled><name>B</name></userPermissions></Profile>"),
+				"objects/Book__c.object": []byte("<CustomObject><fields><fullName>Genre__c</fullName><valueSet><valueSetDefinition>" +
+					"<value><fullName>Fiction</fullName></value><value><fullName>Poetry</fullName></value></valueSetDefinition></valueSet></fields></CustomObject>"),
+				"aura/myCmp/myCmp.cmp": []byte("<aura:component/>"),
+			}
+			org := ForceMetadataFiles{
+				"package.xml":         []byte("<Package/>"),
+				"classes/Changed.cls": []byte("class Changed {}"),
+				"classes/Same.cls":    []byte("class Same {}\n"),
+				"classes/Old.cls":     []byte("class Old {}"),
+				"profiles/Admin.profile": []byte("<?xml version=\"1.0\"?><Profile><userPermissions><enabled>true</enabled><name>B</name></userPermissions>" +
+					"<userPermissions><enabled>true</enabled><name>A</name></userPermissions></Profile>"),
+				"objects/Book__c.object": []byte("<CustomObject><fields><fullName>Genre__c</fullName><valueSet><valueSetDefinition>" +
+					"<value><fullName>Poetry</fullName></value><value><fullName>Fiction</fullName></value></valueSetDefinition></valueSet></fields></CustomObject>"),
+				"aura/myCmp/myCmp.cmp": []byte("<aura:component/>"),
+				"aura/myCmp/myCmp.css": []byte(".THIS {}"),
+			}
+			statuses := make(map[string]ComponentStatus)
+			for _, c := range DiffMetadataFiles(local, org) {
+				statuses[c.Type+":"+c.Name] = c.Status
+			}
+			Expect(statuses).To(Equal(map[string]ComponentStatus{
+				"ApexClass:New":              ComponentNew,
+				"ApexClass:Changed":          ComponentChanged,
+				"ApexClass:Same":             ComponentUnchanged,
+				"ApexClass:Old":              ComponentOnlyInOrg,
+				"Profile:Admin":              ComponentUnchanged,
+				"CustomObject:Book__c":       ComponentChanged,
+				"AuraDefinitionBundle:myCmp": ComponentChanged,
+			}))
+		})
+	})
+})
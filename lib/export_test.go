@@ -0,0 +1,59 @@
+package lib
+
+// Unexported functions used by the specs in lib_test
+
+var (
+	MergeRequestsXml        = mergeRequestsXml
+	CancelDeployXml         = cancelDeployXml
+	WriteDeployReport       = writeDeployReport
+	NewAsyncTestsRequest    = newAsyncTestsRequest
+	DeployRequestFromRecord = deployRequestFromRecord
+	LatestQuickDeployable   = latestQuickDeployable
+	CheckDeploySize         = checkDeploySize
+	MetadataInnerXml        = metadataInnerXml
+	ParseMetadataSaveResult = parseMetadataSaveResult
+	RunOrgDeploys           = deployToOrgs
+	DecodeZipFileElement    = decodeZipFileElement
+	SplitMetadataQuery      = splitMetadataQuery
+	SplitSnapshotComponents = splitSnapshotComponents
+	ExpandWildcardMembers   = expandWildcardMembers
+	AddNewObjectFields      = addNewObjectFields
+	MergeTestSuiteClasses   = mergeTestSuiteClasses
+	TestSuiteClassIdsToAdd  = testSuiteClassIdsToAdd
+	MembershipIdsToRemove   = testSuiteMembershipIdsToRemove
+	NewToolingRecord        = newToolingRecord
+)
+
+func (s *Soap) Envelope(action, query string) string {
+	return s.envelope(action, query)
+}
+
+func (profile ExportProfile) SelectsComponents(metaName string) bool {
+	return profile.selectsComponents(metaName)
+}
+
+func (profile ExportProfile) SelectMembers(metaName string, properties []MDFileProperties) []string {
+	return profile.selectMembers(metaName, properties)
+}
+
+func ContainerAsyncRequestProblems(r containerAsyncRequest, files []ToolingFile) []ToolingSaveError {
+	return r.problems(files)
+}
+
+type ContainerAsyncRequest = containerAsyncRequest
+
+type Metapath struct {
+	Path       string
+	Name       string
+	HasFolder  bool
+	OnlyFolder bool
+	Extension  string
+	MetaFile   bool
+}
+
+func MetapathsFromDescribe(objects []DescribeMetadataObject) (mps []Metapath) {
+	for _, mp := range metapathsFromDescribe(objects) {
+		mps = append(mps, Metapath{mp.path, mp.name, mp.hasFolder, mp.onlyFolder, mp.extension, mp.metaFile})
+	}
+	return
+}
@@ -0,0 +1,217 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Export profiles select the metadata retrieved by export.  The metadata
+// types default to every type returned by describeMetadata.
+//
+// An example profile:
+//
+//	{
+//	  "excludeTypes": ["*Settings", "Document"],
+//	  "exclude": ["ApexClass:*_Test"],
+//	  "standardObjects": ["Account", "Contact", "Opportunity"],
+//	  "namespaces": ["acme"]
+//	}
+type ExportProfile struct {
+	// Metadata types to export.  Types may contain * wildcards.
+	Types []string `json:"types"`
+	// Metadata types not to export
+	ExcludeTypes []string `json:"excludeTypes"`
+	// Component patterns to include and exclude, as used by ManifestFilter
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+	// Standard objects to export.  All standard objects are exported if this
+	// isn't set; an empty list exports none.
+	StandardObjects []string `json:"standardObjects"`
+	// Namespaces of managed packages whose components are exported
+	Namespaces []string `json:"namespaces"`
+}
+
+// Read an export profile from a JSON file
+func LoadExportProfile(path string) (profile ExportProfile, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &profile); err != nil {
+		err = fmt.Errorf("Could not read export profile %s: %s", path, err.Error())
+	}
+	return
+}
+
+// Determine whether the profile exports a metadata type
+func (profile ExportProfile) IncludesType(metaName string) bool {
+	if len(profile.Types) > 0 && !matchesAnyGlob(profile.Types, metaName) {
+		return false
+	}
+	return !matchesAnyGlob(profile.ExcludeTypes, metaName)
+}
+
+// Determine whether the profile exports a standard object
+func (profile ExportProfile) IncludesStandardObject(name string) bool {
+	if profile.StandardObjects == nil {
+		return true
+	}
+	return matchesAnyGlob(profile.StandardObjects, name)
+}
+
+func (profile ExportProfile) filter() ManifestFilter {
+	return ManifestFilter{Include: profile.Include, Exclude: profile.Exclude}
+}
+
+// Determine whether the profile selects individual components of a type, so
+// its components have to be listed rather than retrieved with a wildcard
+func (profile ExportProfile) selectsComponents(metaName string) bool {
+	return len(profile.Namespaces) > 0 || profile.hasComponentPatterns(metaName)
+}
+
+func (profile ExportProfile) hasComponentPatterns(metaName string) bool {
+	for _, pattern := range append(profile.Include, profile.Exclude...) {
+		parts := strings.SplitN(pattern, ":", 2)
+		if len(parts) == 2 && globMatch(strings.ToLower(parts[0]), strings.ToLower(metaName)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select the components of a type listed in the org that the profile
+// exports.  Unmanaged components are retrieved with a wildcard unless the
+// profile has patterns for the type's components.
+func (profile ExportProfile) selectMembers(metaName string, properties []MDFileProperties) (members []string) {
+	filter := profile.filter()
+	patterns := profile.hasComponentPatterns(metaName)
+	if !patterns && filter.Matches(metaName, "*") {
+		members = append(members, "*")
+	}
+	for _, p := range properties {
+		if p.NamespacePrefix == "" && !patterns {
+			continue
+		}
+		if p.NamespacePrefix != "" && !containsFold(profile.Namespaces, p.NamespacePrefix) {
+			continue
+		}
+		if filter.Matches(metaName, p.FullName) {
+			members = append(members, p.FullName)
+		}
+	}
+	sort.Strings(members)
+	return
+}
+
+func uniqueStrings(list []string) (unique []string) {
+	seen := make(map[string]bool)
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			unique = append(unique, s)
+		}
+	}
+	return
+}
+
+func matchesAnyGlob(patterns []string, s string) bool {
+	for _, pattern := range patterns {
+		if globMatch(strings.ToLower(pattern), strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the names of the metadata types in the org from describeMetadata
+func (fm *ForceMetadata) MetadataTypeNames() (types []string, err error) {
+	describe, err := fm.DescribeMetadata()
+	if err != nil {
+		return
+	}
+	for _, object := range describe.MetadataObjects {
+		types = append(types, object.XmlName)
+	}
+	sort.Strings(types)
+	return
+}
+
+// Build the query to export the metadata types selected by a profile.
+// Types that can't be listed are skipped with a warning.
+func (f *Force) ExportQuery(profile ExportProfile, types []string) (query ForceMetadataQuery, err error) {
+	filter := profile.filter()
+	var folders FolderedMetadata
+	for _, metadataType := range types {
+		var members []string
+		var listErr error
+		switch {
+		case folderTypes[metadataType] != "":
+			if folders == nil {
+				if folders, err = f.GetAllFolders(); err != nil {
+					err = fmt.Errorf("Could not get folders: %s", err.Error())
+					return
+				}
+			}
+			var inFolders []string
+			inFolders, listErr = f.GetMetadataInFolders(FolderType(metadataType), folders[folderTypes[metadataType]])
+			for _, member := range inFolders {
+				if member == "*" || filter.Matches(metadataType, member) {
+					members = append(members, member)
+				}
+			}
+		case profile.selectsComponents(metadataType):
+			var properties []MDFileProperties
+			properties, listErr = f.Metadata.listMetadataProperties(metadataType)
+			members = profile.selectMembers(metadataType, properties)
+		case filter.Matches(metadataType, "*"):
+			members = []string{"*"}
+		}
+		if listErr != nil {
+			fmt.Fprintf(os.Stderr, "Could not list %s: %s\n", metadataType, listErr.Error())
+			continue
+		}
+		if metadataType == "CustomObject" {
+			var standardObjects []string
+			if standardObjects, err = f.standardObjects(profile); err != nil {
+				return
+			}
+			members = uniqueStrings(append(members, standardObjects...))
+		}
+		if len(members) > 0 {
+			query = append(query, ForceMetadataQueryElement{Name: []string{metadataType}, Members: members})
+		}
+	}
+	return
+}
+
+// Get the names of the standard objects selected by a profile
+func (f *Force) standardObjects(profile ExportProfile) (names []string, err error) {
+	sobjects, err := f.ListSobjects()
+	if err != nil {
+		return
+	}
+	filter := profile.filter()
+	for _, sobject := range append(sobjects, ForceSobject{"name": "Activity", "custom": false}) {
+		name := sobject["name"].(string)
+		if sobject["custom"].(bool) || strings.HasSuffix(name, "__Tag") || strings.HasSuffix(name, "__History") || strings.HasSuffix(name, "__Share") {
+			continue
+		}
+		if profile.IncludesStandardObject(name) && filter.Matches("CustomObject", name) {
+			names = append(names, name)
+		}
+	}
+	return
+}
@@ -0,0 +1,99 @@
+package lib_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExportProfile", func() {
+	Describe("IncludesType", func() {
+		It("should exclude types matching a pattern", func() {
+			profile := ExportProfile{ExcludeTypes: []string{"*Settings", "Document"}}
+			for name, expected := range map[string]bool{
+				"ApexClass":        true,
+				"AccountSettings":  false,
+				"Settings":         false,
+				"document":         false,
+				"DocumentFolder":   true,
+				"CustomObject":     true,
+				"CustomObjectType": true,
+			} {
+				Expect(profile.IncludesType(name)).To(Equal(expected), name)
+			}
+		})
+		It("should only include the listed types", func() {
+			profile := ExportProfile{Types: []string{"Apex*"}, ExcludeTypes: []string{"ApexPage"}}
+			Expect(profile.IncludesType("ApexClass")).To(BeTrue())
+			Expect(profile.IncludesType("ApexPage")).To(BeFalse())
+			Expect(profile.IncludesType("Layout")).To(BeFalse())
+		})
+	})
+
+	Describe("IncludesStandardObject", func() {
+		It("should include all standard objects by default", func() {
+			Expect(ExportProfile{}.IncludesStandardObject("Account")).To(BeTrue())
+		})
+		It("should include no standard objects if the list is empty", func() {
+			Expect(ExportProfile{StandardObjects: []string{}}.IncludesStandardObject("Account")).To(BeFalse())
+		})
+		It("should only include the listed standard objects", func() {
+			profile := ExportProfile{StandardObjects: []string{"Account", "Contact"}}
+			Expect(profile.IncludesStandardObject("Contact")).To(BeTrue())
+			Expect(profile.IncludesStandardObject("Lead")).To(BeFalse())
+		})
+	})
+
+	Describe("SelectMembers", func() {
+		properties := []MDFileProperties{
+			{FullName: "MyClass"},
+			{FullName: "MyClass_Test"},
+			{FullName: "acme__Widget", NamespacePrefix: "acme"},
+			{FullName: "other__Thing", NamespacePrefix: "other"},
+		}
+
+		It("should select components in the listed namespaces", func() {
+			profile := ExportProfile{Namespaces: []string{"acme"}}
+			Expect(profile.SelectsComponents("ApexClass")).To(BeTrue())
+			Expect(profile.SelectMembers("ApexClass", properties)).To(Equal([]string{"*", "acme__Widget"}))
+		})
+		It("should skip excluded components", func() {
+			profile := ExportProfile{Exclude: []string{"ApexClass:*_Test"}}
+			Expect(profile.SelectsComponents("ApexPage")).To(BeFalse())
+			Expect(profile.SelectMembers("ApexClass", properties)).To(Equal([]string{"MyClass"}))
+		})
+	})
+
+	Describe("LoadExportProfile", func() {
+		var (
+			tempDir string
+			path    string
+		)
+
+		BeforeEach(func() {
+			tempDir, _ = ioutil.TempDir("", "exportprofile-test")
+			path = filepath.Join(tempDir, "export.json")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should read the profile", func() {
+			ioutil.WriteFile(path, []byte(`{"types": ["ApexClass"], "standardObjects": [], "namespaces": ["acme"]}`), 0644)
+			profile, err := LoadExportProfile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(profile).To(Equal(ExportProfile{Types: []string{"ApexClass"}, StandardObjects: []string{}, Namespaces: []string{"acme"}}))
+		})
+		It("should reject an invalid profile", func() {
+			ioutil.WriteFile(path, []byte(`{"types": "ApexClass"}`), 0644)
+			_, err := LoadExportProfile(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
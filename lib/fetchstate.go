@@ -0,0 +1,229 @@
+package lib
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Tracking of the components retrieved by an incremental fetch.  The
+// lastModifiedDate and fileName of each component returned by listMetadata
+// are saved in the fetch directory so the next fetch only has to retrieve the
+// components that were added or changed since.
+
+// Name of the file in the fetch directory that holds the fetch state
+const FetchStateFile = ".force-fetch.json"
+
+// The version of a component in the org when it was fetched
+type ComponentVersion struct {
+	Type             string    `json:"type"`
+	FullName         string    `json:"fullName"`
+	FileName         string    `json:"fileName"`
+	LastModifiedDate time.Time `json:"lastModifiedDate"`
+}
+
+// Component versions keyed by metadata type and full name
+type FetchState map[string]ComponentVersion
+
+func componentKey(metaName string, member string) string {
+	return metaName + ":" + member
+}
+
+// Load the fetch state saved in a directory.  An empty state is returned if
+// the directory has never been fetched incrementally.
+func LoadFetchState(root string) (state FetchState, err error) {
+	state = make(FetchState)
+	data, err := ioutil.ReadFile(filepath.Join(root, FetchStateFile))
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	var versions []ComponentVersion
+	if err = json.Unmarshal(data, &versions); err != nil {
+		err = fmt.Errorf("Could not read %s: %s", FetchStateFile, err.Error())
+		return
+	}
+	for _, v := range versions {
+		state[componentKey(v.Type, v.FullName)] = v
+	}
+	return
+}
+
+// Save the fetch state in a directory
+func (state FetchState) Save(root string) (err error) {
+	data, err := json.MarshalIndent(state.sorted(), "", "  ")
+	if err != nil {
+		return
+	}
+	return ioutil.WriteFile(filepath.Join(root, FetchStateFile), append(data, '\n'), 0644)
+}
+
+func (state FetchState) sorted() (versions []ComponentVersion) {
+	versions = []ComponentVersion{}
+	for _, v := range state {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return componentKey(versions[i].Type, versions[i].FullName) < componentKey(versions[j].Type, versions[j].FullName)
+	})
+	return
+}
+
+// Get the metadata types in the fetch state
+func (state FetchState) Types() (types []string) {
+	seen := make(map[string]bool)
+	for _, v := range state {
+		if !seen[v.Type] {
+			seen[v.Type] = true
+			types = append(types, v.Type)
+		}
+	}
+	sort.Strings(types)
+	return
+}
+
+// Compare the current versions of the components in the org with a previous
+// fetch.  Components of types that weren't listed are ignored.
+func (state FetchState) Changes(current FetchState, types []string) (changed []ComponentVersion, removed []ComponentVersion) {
+	listed := make(map[string]bool)
+	for _, t := range types {
+		listed[t] = true
+	}
+	for _, v := range current.sorted() {
+		previous, found := state[componentKey(v.Type, v.FullName)]
+		if !found || previous.FileName != v.FileName || !previous.LastModifiedDate.Equal(v.LastModifiedDate) {
+			changed = append(changed, v)
+		}
+	}
+	for _, v := range state.sorted() {
+		if _, found := current[componentKey(v.Type, v.FullName)]; !found && listed[v.Type] {
+			removed = append(removed, v)
+		}
+	}
+	return
+}
+
+// Update the fetch state with the current versions of the components of the
+// listed types
+func (state FetchState) Update(current FetchState, types []string) {
+	listed := make(map[string]bool)
+	for _, t := range types {
+		listed[t] = true
+	}
+	for key, v := range state {
+		if listed[v.Type] {
+			delete(state, key)
+		}
+	}
+	for key, v := range current {
+		state[key] = v
+	}
+}
+
+// Reset components to their versions in a previous state, so they are
+// fetched again next time
+func (state FetchState) Revert(previous FetchState, components []ComponentVersion) {
+	for _, c := range components {
+		key := componentKey(c.Type, c.FullName)
+		if v, found := previous[key]; found {
+			state[key] = v
+		} else {
+			delete(state, key)
+		}
+	}
+}
+
+// Get the current versions of the components of the metadata types in the
+// org.  Components in folders are listed folder by folder.
+func (f *Force) ListComponentVersions(types []string) (current FetchState, err error) {
+	current = make(FetchState)
+	var folders FolderedMetadata
+	for _, metadataType := range types {
+		queries := []string{metadataType}
+		if folderType, found := folderTypes[metadataType]; found {
+			if folders == nil {
+				if folders, err = f.GetAllFolders(); err != nil {
+					return
+				}
+			}
+			queries = nil
+			for _, folder := range folders[folderType] {
+				queries = append(queries, fmt.Sprintf("%s:%s", metadataType, folder))
+			}
+		}
+		for _, query := range queries {
+			var properties []MDFileProperties
+			if properties, err = f.Metadata.listMetadataProperties(query); err != nil {
+				err = fmt.Errorf("Could not list %s: %s", metadataType, err.Error())
+				return
+			}
+			for _, p := range properties {
+				current[componentKey(metadataType, p.FullName)] = ComponentVersion{
+					Type:             metadataType,
+					FullName:         p.FullName,
+					FileName:         p.FileName,
+					LastModifiedDate: p.LastModifedDate,
+				}
+			}
+		}
+	}
+	return
+}
+
+func (fm *ForceMetadata) listMetadataProperties(query string) (properties []MDFileProperties, err error) {
+	body, err := fm.ListMetadata(query)
+	if err != nil {
+		return
+	}
+	var res struct {
+		Response ListMetadataResponse `xml:"Body>listMetadataResponse"`
+	}
+	if err = xml.Unmarshal(body, &res); err != nil {
+		return
+	}
+	properties = res.Response.Result
+	return
+}
+
+// Build a query to retrieve a set of components
+func ComponentsQuery(components []ComponentVersion) (query ForceMetadataQuery) {
+	members := make(map[string][]string)
+	var types []string
+	for _, c := range components {
+		if _, found := members[c.Type]; !found {
+			types = append(types, c.Type)
+		}
+		members[c.Type] = append(members[c.Type], c.FullName)
+	}
+	for _, t := range types {
+		query = append(query, ForceMetadataQueryElement{Name: []string{t}, Members: members[t]})
+	}
+	return
+}
+
+// Remove the local files of a component that was fetched in the metadata
+// format, returning the paths removed
+func RemoveComponentFiles(root string, component ComponentVersion) (removed []string, err error) {
+	if component.FileName == "" {
+		return
+	}
+	fpath := filepath.Join(root, filepath.FromSlash(component.FileName))
+	for _, p := range []string{fpath, fpath + "-meta.xml"} {
+		if _, statErr := os.Stat(p); statErr != nil {
+			continue
+		}
+		if err = os.RemoveAll(p); err != nil {
+			return
+		}
+		removed = append(removed, p)
+	}
+	return
+}
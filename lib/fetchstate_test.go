@@ -0,0 +1,128 @@
+package lib_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FetchState", func() {
+	modified := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	version := func(metaName string, member string, fileName string, lastModified time.Time) ComponentVersion {
+		return ComponentVersion{Type: metaName, FullName: member, FileName: fileName, LastModifiedDate: lastModified}
+	}
+
+	Describe("Changes", func() {
+		var previous, current FetchState
+
+		BeforeEach(func() {
+			previous = FetchState{
+				"ApexClass:Unchanged":  version("ApexClass", "Unchanged", "classes/Unchanged.cls", modified),
+				"ApexClass:Edited":     version("ApexClass", "Edited", "classes/Edited.cls", modified),
+				"ApexClass:Deleted":    version("ApexClass", "Deleted", "classes/Deleted.cls", modified),
+				"CustomObject:Book__c": version("CustomObject", "Book__c", "objects/Book__c.object", modified),
+			}
+			current = FetchState{
+				"ApexClass:Unchanged": version("ApexClass", "Unchanged", "classes/Unchanged.cls", modified),
+				"ApexClass:Edited":    version("ApexClass", "Edited", "classes/Edited.cls", modified.Add(time.Hour)),
+				"ApexClass:Added":     version("ApexClass", "Added", "classes/Added.cls", modified),
+			}
+		})
+
+		It("should list changed and removed components of the fetched types", func() {
+			changed, removed := previous.Changes(current, []string{"ApexClass"})
+			Expect(changed).To(Equal([]ComponentVersion{current["ApexClass:Added"], current["ApexClass:Edited"]}))
+			Expect(removed).To(Equal([]ComponentVersion{previous["ApexClass:Deleted"]}))
+		})
+
+		It("should keep components of other types when updated", func() {
+			previous.Update(current, []string{"ApexClass"})
+			Expect(previous).To(HaveLen(4))
+			Expect(previous["ApexClass:Edited"]).To(Equal(current["ApexClass:Edited"]))
+			Expect(previous).To(HaveKey("CustomObject:Book__c"))
+			Expect(previous.Types()).To(Equal([]string{"ApexClass", "CustomObject"}))
+		})
+
+		It("should fetch components that failed to retrieve again", func() {
+			state := FetchState{}
+			state.Update(current, []string{"ApexClass"})
+			state.Revert(FetchState{"ApexClass:Edited": previous["ApexClass:Edited"]},
+				[]ComponentVersion{current["ApexClass:Edited"], current["ApexClass:Added"]})
+			changed, _ := state.Changes(current, []string{"ApexClass"})
+			Expect(changed).To(Equal([]ComponentVersion{current["ApexClass:Added"], current["ApexClass:Edited"]}))
+			Expect(state["ApexClass:Edited"]).To(Equal(previous["ApexClass:Edited"]))
+		})
+	})
+
+	Describe("Save", func() {
+		var tempDir string
+
+		BeforeEach(func() {
+			tempDir, _ = ioutil.TempDir("", "fetchstate-test")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should load the saved state", func() {
+			state, err := LoadFetchState(tempDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(BeEmpty())
+			state["ApexClass:A"] = version("ApexClass", "A", "classes/A.cls", modified)
+			Expect(state.Save(tempDir)).To(Succeed())
+			loaded, err := LoadFetchState(tempDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded).To(Equal(state))
+		})
+	})
+
+	Describe("RemoveComponentFiles", func() {
+		var tempDir string
+
+		BeforeEach(func() {
+			tempDir, _ = ioutil.TempDir("", "fetchstate-test")
+			os.MkdirAll(filepath.Join(tempDir, "classes"), 0755)
+			os.MkdirAll(filepath.Join(tempDir, "aura", "MyCmp"), 0755)
+			ioutil.WriteFile(filepath.Join(tempDir, "classes", "A.cls"), []byte(""), 0644)
+			ioutil.WriteFile(filepath.Join(tempDir, "classes", "A.cls-meta.xml"), []byte(""), 0644)
+			ioutil.WriteFile(filepath.Join(tempDir, "aura", "MyCmp", "MyCmp.cmp"), []byte(""), 0644)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should remove a component's file and meta file", func() {
+			removed, err := RemoveComponentFiles(tempDir, version("ApexClass", "A", "classes/A.cls", modified))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(HaveLen(2))
+		})
+		It("should remove a bundle's directory", func() {
+			removed, err := RemoveComponentFiles(tempDir, version("AuraDefinitionBundle", "MyCmp", "aura/MyCmp", modified))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(HaveLen(1))
+			Expect(filepath.Join(tempDir, "aura", "MyCmp")).ToNot(BeADirectory())
+		})
+	})
+
+	Describe("ComponentsQuery", func() {
+		It("should group components by type", func() {
+			query := ComponentsQuery([]ComponentVersion{
+				{Type: "ApexClass", FullName: "A"},
+				{Type: "ApexPage", FullName: "P"},
+				{Type: "ApexClass", FullName: "B"},
+			})
+			Expect(query).To(Equal(ForceMetadataQuery{
+				{Name: []string{"ApexClass"}, Members: []string{"A", "B"}},
+				{Name: []string{"ApexPage"}, Members: []string{"P"}},
+			}))
+		})
+	})
+})
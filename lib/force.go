@@ -521,6 +521,11 @@ func (f *Force) GetAuraBundlesList() (bundles AuraDefinitionBundleResult, err er
 	return
 }
 
+func (f *Force) GetLightningComponentBundles() (bundles ForceQueryResult, err error) {
+	bundles, err = f.toolingQuery("SELECT Id, DeveloperName, NamespacePrefix, ApiVersion, Description FROM LightningComponentBundle ORDER BY DeveloperName")
+	return
+}
+
 func (f *Force) GetAuraBundle(bundleName string) (bundles AuraDefinitionBundleResult, definitions AuraDefinitionBundleResult, err error) {
 	bundles, err = f.GetAuraBundleByName(bundleName)
 	if len(bundles.Records) == 0 {
@@ -654,6 +659,13 @@ func (f *Force) QueryAndSend(query string, processor chan<- ForceRecord, options
 	return
 }
 
+// Run a query against the Tooling API
+func (f *Force) toolingQuery(soql string) (result ForceQueryResult, err error) {
+	return f.Query(soql, func(options *QueryOptions) {
+		options.IsTooling = true
+	})
+}
+
 func (f *Force) Query(query string, options ...func(*QueryOptions)) (result ForceQueryResult, err error) {
 	queryOptions := QueryOptions{}
 	for _, option := range options {
@@ -0,0 +1,216 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Canonical formatting of metadata XML.  The org returns lists such as a
+// profile's field permissions in no particular order, so lists of elements
+// identified by a key element are sorted by their keys, and elements are
+// indented consistently.  The order of other elements is kept because it can
+// be significant, e.g. picklist values and layout sections.
+
+// The key elements of lists that can be sorted, by element name
+var sortedElementKeys = map[string][]string{
+	"applicationVisibilities":    {"application"},
+	"businessProcesses":          {"fullName"},
+	"categoryGroupVisibilities":  {"dataCategoryGroup"},
+	"classAccesses":              {"apexClass"},
+	"compactLayouts":             {"fullName"},
+	"customMetadataTypeAccesses": {"name"},
+	"customPermissions":          {"name"},
+	"customSettingAccesses":      {"name"},
+	"externalDataSourceAccesses": {"externalDataSource"},
+	"fieldPermissions":           {"field"},
+	"fieldSets":                  {"fullName"},
+	"fields":                     {"fullName"},
+	"flowAccesses":               {"flow"},
+	"indexes":                    {"fullName"},
+	"labels":                     {"fullName"},
+	"layoutAssignments":          {"layout", "recordType"},
+	"listViews":                  {"fullName"},
+	"objectPermissions":          {"object"},
+	"pageAccesses":               {"apexPage"},
+	"recordTypeVisibilities":     {"recordType"},
+	"recordTypes":                {"fullName"},
+	"sharingReasons":             {"fullName"},
+	"tabSettings":                {"tab"},
+	"tabVisibilities":            {"tab"},
+	"userPermissions":            {"name"},
+	"validationRules":            {"fullName"},
+	"webLinks":                   {"fullName"},
+}
+
+// Format a metadata XML document in the canonical form
+func FormatMetadataXml(data []byte) (formatted []byte, err error) {
+	data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+	root, err := parseXmlNode(data)
+	if err != nil {
+		return
+	}
+	root.canonicalize()
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	root.write(&b, 0)
+	formatted = b.Bytes()
+	return
+}
+
+// Format the metadata XML files in a set of files.  Other files, such as
+// Apex classes and static resources, are left as is.
+func FormatMetadataFiles(files ForceMetadataFiles) (formatted ForceMetadataFiles, err error) {
+	formatted = make(ForceMetadataFiles)
+	for name, data := range files {
+		if IsMetadataXmlFile(name) {
+			if data, err = FormatMetadataXml(data); err != nil {
+				err = fmt.Errorf("Could not format %s: %s", name, err.Error())
+				return
+			}
+		}
+		formatted[name] = data
+	}
+	return
+}
+
+// Determine whether a file contains metadata XML, i.e. it's a -meta.xml file
+// or a component of a type that has no separate content file
+func IsMetadataXmlFile(fpath string) bool {
+	fpath = filepath.FromSlash(fpath)
+	if filepath.Ext(fpath) == ".xml" {
+		return true
+	}
+	if filepath.Dir(fpath) == "." {
+		return false
+	}
+	mp := findMetapathForFile(fpath)
+	return mp.name != "" && !mp.metaFile && !isBundleType(mp.name)
+}
+
+// Sort the lists of keyed elements within a node
+func (node *xmlNode) canonicalize() {
+	for _, child := range node.children {
+		child.canonicalize()
+	}
+	for start := 0; start < len(node.children); {
+		end := start + 1
+		for end < len(node.children) && node.children[end].name == node.children[start].name {
+			end++
+		}
+		if keys, found := sortedElementKeys[node.children[start].name]; found {
+			run := node.children[start:end]
+			sort.SliceStable(run, func(i, j int) bool {
+				return run[i].key(keys) < run[j].key(keys)
+			})
+		}
+		start = end
+	}
+}
+
+func (node *xmlNode) key(keys []string) string {
+	var values []string
+	for _, k := range keys {
+		value := ""
+		for _, child := range node.children {
+			if child.name == k {
+				value = child.text
+				break
+			}
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, "\x00")
+}
+
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	text     string
+	children []*xmlNode
+}
+
+// Parse an XML document into a tree of elements, discarding comments,
+// processing instructions, and whitespace between elements
+func parseXmlNode(data []byte) (root *xmlNode, err error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xmlNode
+	for {
+		var token xml.Token
+		token, err = decoder.RawToken()
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: xmlName(t.Name), attrs: t.Copy().Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				err = fmt.Errorf("unexpected end element %s", xmlName(t.Name))
+				return
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil || len(stack) > 0 {
+		err = fmt.Errorf("incomplete XML document")
+	}
+	return
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// Escaping that leaves newlines and quotes in text as is so multi-line values
+// such as formulas are readable
+var xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+var xmlAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// Write a node with each element on its own line, indented by four spaces per
+// level.  Text is kept as is in elements without children.
+func (node *xmlNode) write(w *bytes.Buffer, depth int) {
+	indent := strings.Repeat("    ", depth)
+	w.WriteString(indent + "<" + node.name)
+	for _, attr := range node.attrs {
+		fmt.Fprintf(w, " %s=\"%s\"", xmlName(attr.Name), xmlAttrEscaper.Replace(attr.Value))
+	}
+	if len(node.children) == 0 {
+		if node.text == "" {
+			w.WriteString("/>\n")
+			return
+		}
+		w.WriteString(">" + xmlTextEscaper.Replace(node.text) + "</" + node.name + ">\n")
+		return
+	}
+	w.WriteString(">\n")
+	if text := strings.TrimSpace(node.text); text != "" {
+		w.WriteString(indent + "    " + xmlTextEscaper.Replace(text) + "\n")
+	}
+	for _, child := range node.children {
+		child.write(w, depth+1)
+	}
+	w.WriteString(indent + "</" + node.name + ">\n")
+}
@@ -0,0 +1,113 @@
+package lib_test
+
+import (
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Format", func() {
+	Describe("FormatMetadataXml", func() {
+		It("should sort keyed lists and indent consistently", func() {
+			profile := `<?xml version="1.0" encoding="UTF-8"?>
+<Profile xmlns="http://soap.sforce.com/2006/04/metadata">
+  <fieldPermissions><editable>true</editable><field>Account.Zip__c</field></fieldPermissions>
+  <fieldPermissions>
+    <editable>false</editable>
+    <field>Account.City__c</field>
+  </fieldPermissions>
+  <custom>false</custom>
+  <layoutAssignments><layout>Account-B</layout></layoutAssignments>
+  <layoutAssignments><layout>Account-A</layout><recordType>Account.Z</recordType></layoutAssignments>
+  <layoutAssignments><layout>Account-A</layout><recordType>Account.Y</recordType></layoutAssignments>
+</Profile>`
+			formatted, err := FormatMetadataXml([]byte(profile))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(formatted)).To(Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<Profile xmlns="http://soap.sforce.com/2006/04/metadata">
+    <fieldPermissions>
+        <editable>false</editable>
+        <field>Account.City__c</field>
+    </fieldPermissions>
+    <fieldPermissions>
+        <editable>true</editable>
+        <field>Account.Zip__c</field>
+    </fieldPermissions>
+    <custom>false</custom>
+    <layoutAssignments>
+        <layout>Account-A</layout>
+        <recordType>Account.Y</recordType>
+    </layoutAssignments>
+    <layoutAssignments>
+        <layout>Account-A</layout>
+        <recordType>Account.Z</recordType>
+    </layoutAssignments>
+    <layoutAssignments>
+        <layout>Account-B</layout>
+    </layoutAssignments>
+</Profile>
+`))
+		})
+
+		It("should keep the order of unkeyed lists and multi-line text", func() {
+			object := `<?xml version="1.0" encoding="UTF-8"?>
+<CustomObject xmlns="http://soap.sforce.com/2006/04/metadata">
+    <fields>
+        <fullName>Stage__c</fullName>
+        <valueSet><valueSetDefinition>
+            <value><fullName>Open</fullName></value>
+            <value><fullName>Closed</fullName></value>
+        </valueSetDefinition></valueSet>
+    </fields>
+    <fields>
+        <fullName>Amount__c</fullName>
+        <formula>IF(A &lt; B,
+  1, 2)</formula>
+    </fields>
+</CustomObject>
+`
+			formatted, err := FormatMetadataXml([]byte(object))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(formatted)).To(Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<CustomObject xmlns="http://soap.sforce.com/2006/04/metadata">
+    <fields>
+        <fullName>Amount__c</fullName>
+        <formula>IF(A &lt; B,
+  1, 2)</formula>
+    </fields>
+    <fields>
+        <fullName>Stage__c</fullName>
+        <valueSet>
+            <valueSetDefinition>
+                <value>
+                    <fullName>Open</fullName>
+                </value>
+                <value>
+                    <fullName>Closed</fullName>
+                </value>
+            </valueSetDefinition>
+        </valueSet>
+    </fields>
+</CustomObject>
+`))
+		})
+
+		It("should be idempotent", func() {
+			formatted, _ := FormatMetadataXml([]byte(`<?xml version="1.0" encoding="UTF-8"?><Profile><userPermissions><name>B</name></userPermissions><userPermissions><name>A</name></userPermissions></Profile>`))
+			again, _ := FormatMetadataXml(formatted)
+			Expect(string(again)).To(Equal(string(formatted)))
+		})
+	})
+
+	Describe("IsMetadataXmlFile", func() {
+		It("should only match metadata XML", func() {
+			Expect(IsMetadataXmlFile("src/profiles/Admin.profile")).To(BeTrue())
+			Expect(IsMetadataXmlFile("src/objects/Account.object")).To(BeTrue())
+			Expect(IsMetadataXmlFile("src/classes/MyClass.cls-meta.xml")).To(BeTrue())
+			Expect(IsMetadataXmlFile("src/classes/MyClass.cls")).To(BeFalse())
+			Expect(IsMetadataXmlFile("src/staticresources/jquery.resource")).To(BeFalse())
+			Expect(IsMetadataXmlFile("src/aura/myCmp/myCmp.cmp")).To(BeFalse())
+		})
+	})
+})
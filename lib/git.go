@@ -0,0 +1,120 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Get the files that have changed between a git ref and the working tree,
+// including untracked files that aren't ignored, or between two git refs if
+// until isn't empty.  Paths are absolute.  Renamed files are reported as a
+// deletion of the old path and an addition of the new one.
+func GitChangedFiles(since string, until string) (changed []string, deleted []string, err error) {
+	top, err := gitTopLevel()
+	if err != nil {
+		return
+	}
+
+	args := []string{"-C", top, "diff", "--name-status", "--no-renames", since}
+	if until != "" {
+		args = append(args, until)
+	}
+	out, err := git(args...)
+	if err != nil {
+		return
+	}
+	changed, deleted = parseGitNameStatus(top, out)
+	if until != "" {
+		return
+	}
+
+	untracked, err := git("-C", top, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return
+	}
+	scanner := bufio.NewScanner(strings.NewReader(untracked))
+	for scanner.Scan() {
+		if scanner.Text() != "" {
+			changed = append(changed, filepath.Join(top, filepath.FromSlash(scanner.Text())))
+		}
+	}
+	return
+}
+
+// Get the files in the repository at a git ref.  Paths are absolute.
+func GitTreeFiles(ref string) (files []string, err error) {
+	top, err := gitTopLevel()
+	if err != nil {
+		return
+	}
+	out, err := git("-C", top, "ls-tree", "-r", "--name-only", "--full-tree", ref)
+	if err != nil {
+		return
+	}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		if scanner.Text() != "" {
+			files = append(files, filepath.Join(top, filepath.FromSlash(scanner.Text())))
+		}
+	}
+	return
+}
+
+// Get the contents of a file at a git ref rather than from the working tree
+func GitShowFile(ref string, path string) (data []byte, err error) {
+	top, err := gitTopLevel()
+	if err != nil {
+		return
+	}
+	rel, err := filepath.Rel(top, path)
+	if err != nil {
+		return
+	}
+	out, err := git("-C", top, "show", ref+":"+filepath.ToSlash(rel))
+	if err != nil {
+		return
+	}
+	data = []byte(out)
+	return
+}
+
+func gitTopLevel() (top string, err error) {
+	top, err = git("rev-parse", "--show-toplevel")
+	top = strings.TrimSpace(top)
+	return
+}
+
+func parseGitNameStatus(top string, out string) (changed []string, deleted []string) {
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		path := filepath.Join(top, filepath.FromSlash(fields[len(fields)-1]))
+		switch fields[0][0] {
+		case 'D':
+			deleted = append(deleted, path)
+		default:
+			changed = append(changed, path)
+		}
+	}
+	return
+}
+
+func git(args ...string) (output string, err error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+		return
+	}
+	output = string(out)
+	return
+}
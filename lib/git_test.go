@@ -0,0 +1,90 @@
+package lib_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Git", func() {
+	Describe("GitChangedFiles", func() {
+		var (
+			tempDir string
+			classes string
+			wd      string
+		)
+
+		write := func(name string, content string) {
+			ioutil.WriteFile(filepath.Join(classes, name), []byte(content), 0644)
+		}
+		run := func(args ...string) {
+			cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+			cmd.Dir = tempDir
+			out, err := cmd.CombinedOutput()
+			Expect(err).ToNot(HaveOccurred(), string(out))
+		}
+
+		BeforeEach(func() {
+			if _, err := exec.LookPath("git"); err != nil {
+				Skip("git not installed")
+			}
+			tempDir, _ = ioutil.TempDir("", "git-test")
+			tempDir, _ = filepath.EvalSymlinks(tempDir)
+			classes = filepath.Join(tempDir, "src", "classes")
+			os.MkdirAll(classes, 0755)
+			write("Changed.cls", "class Changed {}")
+			write("Deleted.cls", "class Deleted {}")
+			ioutil.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte("*.log\n"), 0644)
+			run("init", "-q")
+			run("add", ".")
+			run("commit", "-q", "-m", "initial")
+			wd, _ = os.Getwd()
+			os.Chdir(tempDir)
+		})
+
+		AfterEach(func() {
+			os.Chdir(wd)
+			os.RemoveAll(tempDir)
+		})
+
+		It("should list files changed in the working tree", func() {
+			write("Changed.cls", "class Changed { }")
+			os.Remove(filepath.Join(classes, "Deleted.cls"))
+			write("Untracked.cls", "class Untracked {}")
+			write("debug.log", "ignored")
+
+			changed, deleted, err := GitChangedFiles("HEAD", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(Equal([]string{filepath.Join(classes, "Changed.cls"), filepath.Join(classes, "Untracked.cls")}))
+			Expect(deleted).To(Equal([]string{filepath.Join(classes, "Deleted.cls")}))
+		})
+
+		It("should list files changed between two refs", func() {
+			write("Changed.cls", "class Changed { }")
+			run("rm", "-q", "src/classes/Deleted.cls")
+			run("commit", "-q", "-a", "-m", "second")
+			run("tag", "second")
+			write("Changed.cls", "class Changed { working }")
+			write("Untracked.cls", "class Untracked {}")
+
+			changed, deleted, err := GitChangedFiles("HEAD^", "second")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(Equal([]string{filepath.Join(classes, "Changed.cls")}))
+			Expect(deleted).To(Equal([]string{filepath.Join(classes, "Deleted.cls")}))
+
+			data, err := GitShowFile("second", filepath.Join(classes, "Changed.cls"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("class Changed { }"))
+
+			files, err := GitTreeFiles("second")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(Equal([]string{filepath.Join(tempDir, ".gitignore"), filepath.Join(classes, "Changed.cls")}))
+		})
+	})
+})
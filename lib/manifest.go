@@ -0,0 +1,126 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Include and exclude patterns for the components in a package.xml.  A
+// pattern is a metadata type, e.g. ApexClass, or a metadata type and member,
+// e.g. ApexClass:*_Test.  Either part may contain * wildcards.
+type ManifestFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// Determine whether a component should be included in the package
+func (filter ManifestFilter) Matches(metaName string, member string) bool {
+	if len(filter.Include) > 0 && !matchesAnyPattern(filter.Include, metaName, member) {
+		return false
+	}
+	return !matchesAnyPattern(filter.Exclude, metaName, member)
+}
+
+func matchesAnyPattern(patterns []string, metaName string, member string) bool {
+	for _, pattern := range patterns {
+		parts := strings.SplitN(pattern, ":", 2)
+		if !globMatch(strings.ToLower(parts[0]), strings.ToLower(metaName)) {
+			continue
+		}
+		if len(parts) == 1 || globMatch(parts[1], member) {
+			return true
+		}
+	}
+	return false
+}
+
+func globMatch(pattern string, s string) bool {
+	expr := strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1)
+	matched, _ := regexp.MatchString("^"+expr+"$", s)
+	return matched
+}
+
+// Remove the components that don't match the filter from the package
+func (pb *PackageBuilder) Filter(filter ManifestFilter) {
+	for metaName, metaType := range pb.Metadata {
+		var members []string
+		for _, member := range metaType.Members {
+			if filter.Matches(metaName, member) {
+				members = append(members, member)
+			}
+		}
+		if len(members) == 0 {
+			delete(pb.Metadata, metaName)
+			continue
+		}
+		metaType.Members = members
+		pb.Metadata[metaName] = metaType
+	}
+}
+
+// Get the names of the components of a metadata type in the org
+func (fm *ForceMetadata) ListMetadataNames(metadataType string) (names []string, err error) {
+	properties, err := fm.listMetadataProperties(metadataType)
+	if err != nil {
+		return
+	}
+	for _, file := range properties {
+		names = append(names, file.FullName)
+	}
+	sort.Strings(names)
+	return
+}
+
+// Folder types by the metadata type stored in the folders
+var folderTypes = map[string]FolderType{
+	"Dashboard":     "Dashboard",
+	"Document":      "Document",
+	"EmailTemplate": "Email",
+	"Report":        "Report",
+}
+
+// Build a package containing all of the components of the metadata types in
+// the org.  If no types are given, all types returned by describeMetadata are
+// included.
+func (f *Force) OrgPackageBuilder(types []string) (pb PackageBuilder, err error) {
+	pb = NewFetchBuilder()
+	if len(types) == 0 {
+		var describe MetadataDescribeResult
+		describe, err = f.Metadata.DescribeMetadata()
+		if err != nil {
+			return
+		}
+		for _, object := range describe.MetadataObjects {
+			types = append(types, object.XmlName)
+		}
+	}
+
+	var folders FolderedMetadata
+	for _, metadataType := range types {
+		var members []string
+		if folderType, found := folderTypes[metadataType]; found {
+			if folders == nil {
+				if folders, err = f.GetAllFolders(); err != nil {
+					return
+				}
+			}
+			members, err = f.GetMetadataInFolders(FolderType(metadataType), folders[folderType])
+		} else {
+			members, err = f.Metadata.ListMetadataNames(metadataType)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not list %s: %s\n", metadataType, err.Error())
+			err = nil
+			continue
+		}
+		for _, member := range members {
+			if member != "*" {
+				pb.AddMetaToPackage(metadataType, member)
+			}
+		}
+	}
+	return
+}
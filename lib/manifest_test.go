@@ -0,0 +1,90 @@
+package lib_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Manifest", func() {
+	Describe("ManifestFilter", func() {
+		It("should include everything by default", func() {
+			filter := ManifestFilter{}
+			Expect(filter.Matches("ApexClass", "MyClass")).To(BeTrue())
+		})
+
+		It("should match metadata types", func() {
+			filter := ManifestFilter{Include: []string{"apexclass", "Custom*"}}
+			Expect(filter.Matches("ApexClass", "MyClass")).To(BeTrue())
+			Expect(filter.Matches("CustomObject", "Book__c")).To(BeTrue())
+			Expect(filter.Matches("ApexTrigger", "MyTrigger")).To(BeFalse())
+		})
+
+		It("should match member names", func() {
+			filter := ManifestFilter{
+				Include: []string{"CustomObject:*__c", "Report"},
+				Exclude: []string{"CustomObject:Old*", "Report:Private/*"},
+			}
+			Expect(filter.Matches("CustomObject", "Book__c")).To(BeTrue())
+			Expect(filter.Matches("CustomObject", "Account")).To(BeFalse())
+			Expect(filter.Matches("CustomObject", "Old_Book__c")).To(BeFalse())
+			Expect(filter.Matches("Report", "Sales/Daily")).To(BeTrue())
+			Expect(filter.Matches("Report", "Private/Daily")).To(BeFalse())
+		})
+	})
+
+	Describe("Filter", func() {
+		It("should remove components and empty types", func() {
+			pb := NewFetchBuilder()
+			pb.AddMetaToPackage("ApexClass", "MyClass")
+			pb.AddMetaToPackage("ApexClass", "MyClass_Test")
+			pb.AddMetaToPackage("Profile", "Admin")
+			pb.Filter(ManifestFilter{Exclude: []string{"ApexClass:*_Test", "Profile"}})
+			Expect(pb.Metadata).To(HaveLen(1))
+			Expect(pb.Metadata["ApexClass"].Members).To(Equal([]string{"MyClass"}))
+		})
+	})
+
+	Describe("PackageXml", func() {
+		It("should sort types and members", func() {
+			pb := NewFetchBuilder()
+			pb.AddMetaToPackage("CustomObject", "Book__c")
+			pb.AddMetaToPackage("ApexClass", "Zebra")
+			pb.AddMetaToPackage("ApexClass", "Apple")
+			Expect(string(pb.PackageXml())).To(MatchRegexp(`(?s)<members>Apple</members>\s*<members>Zebra</members>\s*<name>ApexClass</name>.*<members>Book__c</members>\s*<name>CustomObject</name>`))
+		})
+	})
+
+	Describe("AddDirectory", func() {
+		var tempDir string
+
+		BeforeEach(func() {
+			tempDir, _ = ioutil.TempDir("", "manifest-test")
+			os.MkdirAll(tempDir+"/src/classes", 0755)
+			os.MkdirAll(tempDir+"/src/aura/myCmp", 0755)
+			ioutil.WriteFile(tempDir+"/src/package.xml", []byte("<Package/>"), 0644)
+			ioutil.WriteFile(tempDir+"/src/classes/MyClass.cls", []byte("class MyClass {}"), 0644)
+			ioutil.WriteFile(tempDir+"/src/classes/MyClass.cls-meta.xml", []byte("<ApexClass/>"), 0644)
+			ioutil.WriteFile(tempDir+"/src/classes/.DS_Store", []byte(""), 0644)
+			ioutil.WriteFile(tempDir+"/src/aura/myCmp/myCmp.cmp", []byte("<aura:component/>"), 0644)
+			ioutil.WriteFile(tempDir+"/src/aura/myCmp/.manifest", []byte("{}"), 0644)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should only list the components in the directory", func() {
+			pb := NewFetchBuilder()
+			_, _, err := pb.AddDirectory(tempDir+"/src", AddDirectoryOptions{SkipNonComponents: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pb.Metadata).To(HaveLen(2))
+			Expect(pb.Metadata["ApexClass"].Members).To(Equal([]string{"MyClass"}))
+			Expect(pb.Metadata["AuraDefinitionBundle"].Members).To(Equal([]string{"myCmp"}))
+		})
+	})
+})
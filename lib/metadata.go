@@ -8,6 +8,7 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -209,6 +210,14 @@ type ForceDeployOptions struct {
 	TestLevel         string   `xml:"testLevel,omitempty"`
 	RunTests          []string `xml:"runTests"`
 	SinglePackage     bool     `xml:"singlePackage"`
+	Async             bool     `xml:"-"`
+	Report            string   `xml:"-"`
+	DryRun            bool     `xml:"-"`
+	Split             bool     `xml:"-"`
+	Snapshot          bool     `xml:"-"`
+	To                []string `xml:"-"`
+	Workers           int      `xml:"-"`
+	StopOnFailure     bool     `xml:"-"`
 }
 
 /* These structs define which options are available and which are
@@ -728,45 +737,32 @@ func (fm *ForceMetadata) CheckDeployStatus(id string) (results ForceCheckDeploym
 	}
 
 	if err = xml.Unmarshal(body, &deployResult); err != nil {
-		ErrorAndExit(err.Error())
+		return
 	}
 
 	results = deployResult.Results
 	return
 }
 
+// Get the files retrieved by a retrieve request that has finished.  The zip
+// file is downloaded to a temporary file instead of being held in memory
+// while it's decoded.
 func (fm *ForceMetadata) CheckRetrieveStatus(id string) (files ForceMetadataFiles, problems []string, err error) {
-	body, err := fm.soapExecute("checkRetrieveStatus", fmt.Sprintf("<id>%s</id>", id))
+	zipPath, problems, err := fm.downloadRetrieveZip(id)
 	if err != nil {
 		return
 	}
-	var status struct {
-		ZipFile  string   `xml:"Body>checkRetrieveStatusResponse>result>zipFile"`
-		Problems []string `xml:"Body>checkRetrieveStatusResponse>result>messages>problem"`
-	}
-	if err = xml.Unmarshal(body, &status); err != nil {
-		return
-	}
-	data, err := base64.StdEncoding.DecodeString(status.ZipFile)
-	if err != nil {
-		return
-	}
-	problems = status.Problems
-
-	zipfiles, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	defer os.Remove(zipPath)
 	if preserveZip == true {
-		ioutil.WriteFile("inbound.zip", data, 0644)
-	}
-	if err != nil {
-		return
-	}
-	files = make(map[string][]byte)
-	for _, file := range zipfiles.File {
-		fd, _ := file.Open()
-		defer fd.Close()
-		data, _ := ioutil.ReadAll(fd)
-		files[file.Name] = data
+		if data, err := ioutil.ReadFile(zipPath); err == nil {
+			ioutil.WriteFile("inbound.zip", data, 0644)
+		}
 	}
+	files = make(ForceMetadataFiles)
+	err = extractZip(zipPath, func(name string, data []byte) error {
+		files[name] = data
+		return nil
+	})
 	return
 }
 
@@ -783,6 +779,9 @@ func (fm *ForceMetadata) DescribeMetadata() (describe MetadataDescribeResult, er
 
 	if err == nil {
 		describe = result.Data
+		if saveErr := saveMetadataTypes(apiVersionNumber, describe.MetadataObjects); saveErr != nil {
+			Log.Info("Could not cache metadata types: " + saveErr.Error())
+		}
 	}
 	return
 }
@@ -1231,10 +1230,38 @@ func (fm *ForceMetadata) Deploy(files ForceMetadataFiles, options ForceDeployOpt
 	zipfile, err := fm.MakeZip(files)
 
 	results, err = fm.DeployZipFile(soap, zipfile)
+	if err == nil && results.Success && !options.CheckOnly {
+		if recordErr := fm.recordDeployedFiles(files); recordErr != nil {
+			Log.Info("Could not record deployed files: " + recordErr.Error())
+		}
+	}
 	return
 }
 
 func (fm *ForceMetadata) DeployZipFile(soap string, zipfile []byte) (results ForceCheckDeploymentStatusResult, err error) {
+	id, err := fm.StartDeployZipFile(soap, zipfile)
+	if err != nil {
+		return
+	}
+	return fm.WaitForDeploy(id)
+}
+
+// Start a deploy without waiting for it to finish, returning the deploy id
+func (fm *ForceMetadata) StartDeploy(files ForceMetadataFiles, options ForceDeployOptions) (id string, err error) {
+	soap := fm.MakeDeploySoap(options)
+
+	zipfile, err := fm.MakeZip(files)
+	if err != nil {
+		return
+	}
+
+	return fm.StartDeployZipFile(soap, zipfile)
+}
+
+func (fm *ForceMetadata) StartDeployZipFile(soap string, zipfile []byte) (id string, err error) {
+	if err = checkDeploySize(zipfile); err != nil {
+		return
+	}
 	encoded := base64.StdEncoding.EncodeToString(zipfile)
 	body, err := fm.soapExecute("deploy", fmt.Sprintf(soap, encoded))
 	if err != nil {
@@ -1247,8 +1274,14 @@ func (fm *ForceMetadata) DeployZipFile(soap string, zipfile []byte) (results For
 	if err = xml.Unmarshal(body, &status); err != nil {
 		return
 	}
+	id = status.Id
+	return
+}
+
+// Poll the status of a deploy every five seconds until it is done
+func (fm *ForceMetadata) WaitForDeploy(id string) (results ForceCheckDeploymentStatusResult, err error) {
 	for {
-		results, err = fm.CheckDeployStatus(status.Id)
+		results, err = fm.CheckDeployStatus(id)
 		if err != nil || results.Done {
 			return
 		}
@@ -1257,6 +1290,27 @@ func (fm *ForceMetadata) DeployZipFile(soap string, zipfile []byte) (results For
 	}
 }
 
+// Request cancellation of an in-progress deploy.  Done is false if the
+// cancellation is still pending.
+func (fm *ForceMetadata) CancelDeploy(id string) (done bool, err error) {
+	body, err := fm.soapExecute("cancelDeploy", cancelDeployXml(id))
+	if err != nil {
+		return
+	}
+	var status struct {
+		Done bool `xml:"Body>cancelDeployResponse>result>done"`
+	}
+	if err = xml.Unmarshal(body, &status); err != nil {
+		return
+	}
+	done = status.Done
+	return
+}
+
+func cancelDeployXml(id string) string {
+	return fmt.Sprintf("<String>%s</String>", id)
+}
+
 func (fm *ForceMetadata) DeployRecentValidation(validationId string) (results ForceCheckDeploymentStatusResult, err error) {
 	body, err := fm.soapExecute("deployRecentValidation", fmt.Sprintf("<validationID>%s</validationID>", validationId))
 	if err != nil {
@@ -1269,14 +1323,7 @@ func (fm *ForceMetadata) DeployRecentValidation(validationId string) (results Fo
 	if err = xml.Unmarshal(body, &status); err != nil {
 		return
 	}
-	for {
-		results, err = fm.CheckDeployStatus(status.Id)
-		if err != nil || results.Done {
-			return
-		}
-		Log.Info(results)
-		time.Sleep(5000 * time.Millisecond)
-	}
+	return fm.WaitForDeploy(status.Id)
 }
 
 func (fm *ForceMetadata) RetrieveByPackageXml(package_xml string) (files ForceMetadataFiles, problems []string, err error) {
@@ -1343,7 +1390,27 @@ func (fm *ForceMetadata) RetrieveByPackageXml(package_xml string) (files ForceMe
 }
 
 func (fm *ForceMetadata) Retrieve(query ForceMetadataQuery) (files ForceMetadataFiles, problems []string, err error) {
+	id, err := fm.startRetrieve(query)
+	if err != nil {
+		return
+	}
+	if err = fm.CheckStatus(id); err != nil {
+		return
+	}
+	raw_files, problems, err := fm.CheckRetrieveStatus(id)
+	if err != nil {
+		return
+	}
+	files = make(ForceMetadataFiles)
+	for raw_name, data := range raw_files {
+		name := strings.Replace(raw_name, "unpackaged/", "", -1)
+		files[name] = data
+	}
+	return
+}
 
+// Start retrieving the components in a query, returning the retrieve's id
+func (fm *ForceMetadata) startRetrieve(query ForceMetadataQuery) (id string, err error) {
 	soap := `
 		<retrieveRequest>
 			<apiVersion>%s</apiVersion>
@@ -1379,19 +1446,7 @@ func (fm *ForceMetadata) Retrieve(query ForceMetadataQuery) (files ForceMetadata
 	if err = xml.Unmarshal(body, &status); err != nil {
 		return
 	}
-
-	if err = fm.CheckStatus(status.Id); err != nil {
-		return
-	}
-	raw_files, problems, err := fm.CheckRetrieveStatus(status.Id)
-	if err != nil {
-		return
-	}
-	files = make(ForceMetadataFiles)
-	for raw_name, data := range raw_files {
-		name := strings.Replace(raw_name, "unpackaged/", "", -1)
-		files[name] = data
-	}
+	id = status.Id
 	return
 }
 
@@ -1460,6 +1515,20 @@ func (fm *ForceMetadata) ListConnectedApps() (apps ForceConnectedApps, err error
 	return
 }
 
+func (fm *ForceMetadata) soapExecuteStream(action, query string, process func(io.Reader) error) (err error) {
+	url := fmt.Sprintf("%s/services/Soap/m/%s", fm.Force.Credentials.InstanceUrl, fm.ApiVersion)
+	soap := NewSoap(url, "http://soap.sforce.com/2006/04/metadata", fm.Force.Credentials.AccessToken)
+	err = soap.ExecuteStream(action, query, process)
+	if err == SessionExpiredError {
+		err = fm.Force.RefreshSession()
+		if err != nil {
+			return
+		}
+		return fm.soapExecuteStream(action, query, process)
+	}
+	return
+}
+
 func (fm *ForceMetadata) soapExecute(action, query string) (response []byte, err error) {
 	url := fmt.Sprintf("%s/services/Soap/m/%s", fm.Force.Credentials.InstanceUrl, fm.ApiVersion)
 	soap := NewSoap(url, "http://soap.sforce.com/2006/04/metadata", fm.Force.Credentials.AccessToken)
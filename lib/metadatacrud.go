@@ -0,0 +1,285 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Synchronous CRUD calls for any metadata type.  Components are passed and
+// returned as metadata XML documents, the same as the files retrieved by
+// fetch, or as JSON objects whose keys are element names.
+
+type MetadataSaveResult struct {
+	FullName string              `xml:"fullName"`
+	Success  bool                `xml:"success"`
+	Created  bool                `xml:"created"`
+	Errors   []ForcePartnerError `xml:"errors"`
+}
+
+func (result MetadataSaveResult) Error() string {
+	var messages []string
+	for _, e := range result.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", e.StatusCode, e.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Read a component, returning it as a metadata XML document
+func (fm *ForceMetadata) ReadMetadata(metadataType string, fullName string) (metadata []byte, err error) {
+	soap := fmt.Sprintf("<type>%s</type><fullNames>%s</fullNames>", html.EscapeString(metadataType), html.EscapeString(fullName))
+	body, err := fm.soapExecute("readMetadata", soap)
+	if err != nil {
+		return
+	}
+	var res struct {
+		Records []struct {
+			Inner []byte `xml:",innerxml"`
+		} `xml:"Body>readMetadataResponse>result>records"`
+	}
+	if err = xml.Unmarshal(body, &res); err != nil {
+		return
+	}
+	if len(res.Records) == 0 || !bytes.Contains(res.Records[0].Inner, []byte("<fullName>")) {
+		err = fmt.Errorf("%s %s not found", metadataType, fullName)
+		return
+	}
+	doc := fmt.Sprintf(`<%s xmlns="%s">%s</%s>`, metadataType, metadataNamespace, res.Records[0].Inner, metadataType)
+	metadata, err = FormatMetadataXml([]byte(doc))
+	return
+}
+
+// Create a component from a metadata XML document or JSON object
+func (fm *ForceMetadata) CreateMetadata(metadataType string, fullName string, metadata []byte) (result MetadataSaveResult, err error) {
+	return fm.saveMetadata("createMetadata", metadataType, fullName, metadata)
+}
+
+// Update an existing component from a metadata XML document or JSON object
+func (fm *ForceMetadata) UpdateMetadata(metadataType string, fullName string, metadata []byte) (result MetadataSaveResult, err error) {
+	return fm.saveMetadata("updateMetadata", metadataType, fullName, metadata)
+}
+
+// Create or update a component from a metadata XML document or JSON object
+func (fm *ForceMetadata) UpsertMetadata(metadataType string, fullName string, metadata []byte) (result MetadataSaveResult, err error) {
+	return fm.saveMetadata("upsertMetadata", metadataType, fullName, metadata)
+}
+
+func (fm *ForceMetadata) saveMetadata(action string, metadataType string, fullName string, metadata []byte) (result MetadataSaveResult, err error) {
+	inner, err := metadataInnerXml(fullName, metadata)
+	if err != nil {
+		return
+	}
+	soap := fmt.Sprintf(`<metadata xsi:type="%s">%s</metadata>`, html.EscapeString(metadataType), inner)
+	body, err := fm.soapExecute(action, soap)
+	if err != nil {
+		return
+	}
+	return parseMetadataSaveResult(body)
+}
+
+// Delete a component
+func (fm *ForceMetadata) DeleteMetadata(metadataType string, fullName string) (result MetadataSaveResult, err error) {
+	soap := fmt.Sprintf("<type>%s</type><fullNames>%s</fullNames>", html.EscapeString(metadataType), html.EscapeString(fullName))
+	body, err := fm.soapExecute("deleteMetadata", soap)
+	if err != nil {
+		return
+	}
+	return parseMetadataSaveResult(body)
+}
+
+// Change the full name of a component
+func (fm *ForceMetadata) RenameMetadata(metadataType string, oldFullName string, newFullName string) (result MetadataSaveResult, err error) {
+	soap := fmt.Sprintf("<type>%s</type><oldFullName>%s</oldFullName><newFullName>%s</newFullName>",
+		html.EscapeString(metadataType), html.EscapeString(oldFullName), html.EscapeString(newFullName))
+	body, err := fm.soapExecute("renameMetadata", soap)
+	if err != nil {
+		return
+	}
+	return parseMetadataSaveResult(body)
+}
+
+// Parse the result of a call that saves a single component, returning an
+// error if the component could not be saved
+func parseMetadataSaveResult(body []byte) (result MetadataSaveResult, err error) {
+	var response struct {
+		Body struct {
+			Response struct {
+				Results []MetadataSaveResult `xml:"result"`
+			} `xml:",any"`
+		} `xml:"Body"`
+	}
+	if err = xml.Unmarshal(body, &response); err != nil {
+		return
+	}
+	if len(response.Body.Response.Results) == 0 {
+		err = errors.New("No result returned")
+		return
+	}
+	result = response.Body.Response.Results[0]
+	if !result.Success {
+		err = errors.New(result.Error())
+	}
+	return
+}
+
+// Get the elements within a component's metadata XML document or JSON
+// object, setting the component's fullName
+func metadataInnerXml(fullName string, metadata []byte) (inner []byte, err error) {
+	var root *xmlNode
+	if bytes.HasPrefix(bytes.TrimSpace(metadata), []byte("{")) {
+		root, err = jsonToXmlNode("metadata", metadata)
+	} else {
+		root, err = parseXmlNode(metadata)
+	}
+	if err != nil {
+		return
+	}
+	name := &xmlNode{name: "fullName", text: fullName}
+	found := false
+	for i, child := range root.children {
+		if child.name == "fullName" {
+			root.children[i] = name
+			found = true
+		}
+	}
+	if !found {
+		root.children = append([]*xmlNode{name}, root.children...)
+	}
+	var b bytes.Buffer
+	for _, child := range root.children {
+		child.write(&b, 0)
+	}
+	inner = b.Bytes()
+	return
+}
+
+// Convert a JSON object to an XML element, keeping the order of the keys.
+// Arrays become repeated elements.
+func jsonToXmlNode(name string, data []byte) (node *xmlNode, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return
+	}
+	if token != json.Delim('{') {
+		err = errors.New("metadata must be a JSON object")
+		return
+	}
+	node = &xmlNode{name: name}
+	err = decodeJsonObject(decoder, node)
+	return
+}
+
+func decodeJsonObject(decoder *json.Decoder, node *xmlNode) (err error) {
+	for decoder.More() {
+		var token json.Token
+		if token, err = decoder.Token(); err != nil {
+			return
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("unexpected %v in JSON object", token)
+		}
+		var children []*xmlNode
+		if children, err = decodeJsonValue(decoder, key); err != nil {
+			return
+		}
+		node.children = append(node.children, children...)
+	}
+	_, err = decoder.Token()
+	return
+}
+
+// Decode a JSON value into the elements for a key
+func decodeJsonValue(decoder *json.Decoder, name string) (nodes []*xmlNode, err error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			node := &xmlNode{name: name}
+			err = decodeJsonObject(decoder, node)
+			nodes = append(nodes, node)
+		case '[':
+			for decoder.More() {
+				var items []*xmlNode
+				if items, err = decodeJsonValue(decoder, name); err != nil {
+					return
+				}
+				nodes = append(nodes, items...)
+			}
+			_, err = decoder.Token()
+		default:
+			err = fmt.Errorf("unexpected %v in JSON", t)
+		}
+	case nil:
+	default:
+		nodes = append(nodes, &xmlNode{name: name, text: fmt.Sprintf("%v", t)})
+	}
+	return
+}
+
+// Convert a metadata XML document to JSON.  Elements that occur more than
+// once become arrays.  Keys are kept in document order because the Metadata
+// API requires elements in the order of its schema, so the JSON can be saved
+// back as is.
+func MetadataXmlToJson(metadata []byte) (data []byte, err error) {
+	root, err := parseXmlNode(metadata)
+	if err != nil {
+		return
+	}
+	var b bytes.Buffer
+	root.writeJson(&b, "")
+	data = b.Bytes()
+	return
+}
+
+// Write the element as JSON, indented like json.MarshalIndent.  Repeated
+// elements are written as an array where the first of them occurs.
+func (node *xmlNode) writeJson(b *bytes.Buffer, indent string) {
+	if len(node.children) == 0 {
+		text, _ := json.Marshal(node.text)
+		b.Write(text)
+		return
+	}
+	var names []string
+	elements := make(map[string][]*xmlNode)
+	for _, child := range node.children {
+		if _, seen := elements[child.name]; !seen {
+			names = append(names, child.name)
+		}
+		elements[child.name] = append(elements[child.name], child)
+	}
+	b.WriteString("{\n")
+	for i, name := range names {
+		key, _ := json.Marshal(name)
+		fmt.Fprintf(b, "%s  %s: ", indent, key)
+		if len(elements[name]) == 1 {
+			elements[name][0].writeJson(b, indent+"  ")
+		} else {
+			b.WriteString("[\n")
+			for j, child := range elements[name] {
+				b.WriteString(indent + "    ")
+				child.writeJson(b, indent+"    ")
+				if j < len(elements[name])-1 {
+					b.WriteString(",")
+				}
+				b.WriteString("\n")
+			}
+			b.WriteString(indent + "  ]")
+		}
+		if i < len(names)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(indent + "}")
+}
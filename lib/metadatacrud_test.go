@@ -0,0 +1,95 @@
+package lib_test
+
+import (
+	"strings"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metadatacrud", func() {
+	Describe("MetadataInnerXml", func() {
+		It("should replace the full name in XML metadata", func() {
+			inner, err := MetadataInnerXml("Example", []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<RemoteSiteSetting xmlns="http://soap.sforce.com/2006/04/metadata">
+    <fullName>Old</fullName>
+    <url>https://example.com?a=1&amp;b=2</url>
+</RemoteSiteSetting>`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(inner)).To(Equal("<fullName>Example</fullName>\n<url>https://example.com?a=1&amp;b=2</url>\n"))
+		})
+		It("should convert JSON metadata to XML", func() {
+			inner, err := MetadataInnerXml("Example", []byte(`{"url": "https://example.com", "isActive": true, "labels": [{"value": "A"}, {"value": "B"}], "description": null}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(inner)).To(Equal("<fullName>Example</fullName>\n<url>https://example.com</url>\n<isActive>true</isActive>\n" +
+				"<labels>\n    <value>A</value>\n</labels>\n<labels>\n    <value>B</value>\n</labels>\n"))
+		})
+	})
+
+	Describe("MetadataXmlToJson", func() {
+		It("should convert repeated elements to arrays", func() {
+			data, err := MetadataXmlToJson([]byte(`<Profile><custom>false</custom><userPermissions><name>A</name></userPermissions><userPermissions><name>B</name></userPermissions></Profile>`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`{
+  "custom": "false",
+  "userPermissions": [
+    {
+      "name": "A"
+    },
+    {
+      "name": "B"
+    }
+  ]
+}`))
+		})
+
+		It("should round trip through JSON in document order", func() {
+			metadata := `<?xml version="1.0" encoding="UTF-8"?>
+<CustomField xmlns="http://soap.sforce.com/2006/04/metadata">
+    <fullName>Account.Tier__c</fullName>
+    <label>Tier &amp; Level</label>
+    <type>Picklist</type>
+    <valueSet>
+        <valueSetDefinition>
+            <value>
+                <fullName>Gold</fullName>
+                <default>false</default>
+                <label>Gold</label>
+            </value>
+            <value>
+                <fullName>Silver</fullName>
+                <default>true</default>
+                <label>Silver</label>
+            </value>
+        </valueSetDefinition>
+    </valueSet>
+</CustomField>`
+			data, err := MetadataXmlToJson([]byte(metadata))
+			Expect(err).ToNot(HaveOccurred())
+			json := string(data)
+			Expect(json).To(ContainSubstring(`"label": "Tier`))
+			Expect(json).To(ContainSubstring(`"fullName": "Gold"`))
+			Expect(strings.Index(json, `"label": "Tier`)).To(BeNumerically("<", strings.Index(json, `"type"`)))
+			Expect(strings.Index(json, `"fullName": "Gold"`)).To(BeNumerically("<", strings.Index(json, `"default": "false"`)))
+
+			inner, err := MetadataInnerXml("Account.Tier__c", data)
+			Expect(err).ToNot(HaveOccurred())
+			expected, err := MetadataInnerXml("Account.Tier__c", []byte(metadata))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(inner)).To(Equal(string(expected)))
+		})
+	})
+
+	Describe("ParseMetadataSaveResult", func() {
+		It("should return the errors", func() {
+			result, err := ParseMetadataSaveResult([]byte(`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"><soapenv:Body>
+<upsertMetadataResponse><result><created>false</created><errors><message>Invalid url</message><statusCode>FIELD_INTEGRITY_EXCEPTION</statusCode></errors><fullName>Example</fullName><success>false</success></result></upsertMetadataResponse>
+</soapenv:Body></soapenv:Envelope>`))
+			Expect(err).To(MatchError("FIELD_INTEGRITY_EXCEPTION: Invalid url"))
+			Expect(result.FullName).To(Equal("Example"))
+			Expect(result.Success).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,206 @@
+package lib
+
+import (
+	"encoding/json"
+
+	. "github.com/ForceCLI/force/config"
+)
+
+// The metadata types used to map file paths to metadata types.  The types
+// supported by the org are retrieved using describeMetadata and cached per API
+// version in the config directory.  The built-in types are used when the
+// types have not been described, e.g. before logging in.
+
+type metapath struct {
+	path       string
+	name       string
+	hasFolder  bool
+	onlyFolder bool
+	extension  string
+	metaFile   bool
+}
+
+// Types whose components are directories of files
+var bundleTypes = []string{
+	"AuraDefinitionBundle",
+	"LightningComponentBundle",
+}
+
+var builtinMetapaths = []metapath{
+	metapath{path: "actionLinkGroupTemplates", name: "ActionLinkGroupTemplate"},
+	metapath{path: "analyticSnapshots", name: "AnalyticSnapshot"},
+	metapath{path: "applications", name: "CustomApplication"},
+	metapath{path: "appMenus", name: "AppMenu"},
+	metapath{path: "approvalProcesses", name: "ApprovalProcess"},
+	metapath{path: "assignmentRules", name: "AssignmentRules"},
+	metapath{path: "audience", name: "Audience"},
+	metapath{path: "authproviders", name: "AuthProvider"},
+	metapath{path: "aura", name: "AuraDefinitionBundle", hasFolder: true, onlyFolder: true},
+	metapath{path: "autoResponseRules", name: "AutoResponseRules"},
+	metapath{path: "callCenters", name: "CallCenter"},
+	metapath{path: "cachePartitions", name: "PlatformCachePartition"},
+	metapath{path: "certs", name: "Certificate", metaFile: true},
+	metapath{path: "channelLayouts", name: "ChannelLayout"},
+	metapath{path: "classes", name: "ApexClass", metaFile: true},
+	metapath{path: "communities", name: "Community"},
+	metapath{path: "components", name: "ApexComponent", metaFile: true},
+	metapath{path: "connectedApps", name: "ConnectedApp"},
+	metapath{path: "contentassets", name: "ContentAsset", metaFile: true},
+	metapath{path: "corsWhitelistOrigins", name: "CorsWhitelistOrigin"},
+	metapath{path: "cspTrustedSites", name: "CspTrustedSite"},
+	metapath{path: "customApplicationComponents", name: "CustomApplicationComponent"},
+	metapath{path: "customMetadata", name: "CustomMetadata"},
+	metapath{path: "customPermissions", name: "CustomPermission"},
+	metapath{path: "dashboards", name: "Dashboard", hasFolder: true},
+	metapath{path: "dataSources", name: "ExternalDataSource"},
+	metapath{path: "datacategorygroups", name: "DataCategoryGroup"},
+	metapath{path: "delegateGroups", name: "DelegateGroup"},
+	metapath{path: "documents", name: "Document", hasFolder: true, metaFile: true},
+	metapath{path: "duplicateRules", name: "DuplicateRule"},
+	metapath{path: "EmbeddedServiceConfig", name: "EmbeddedServiceConfig"},
+	metapath{path: "email", name: "EmailTemplate", hasFolder: true, metaFile: true},
+	metapath{path: "escalationRules", name: "EscalationRules"},
+	metapath{path: "externalServiceRegistrations", name: "ExternalServiceRegistration"},
+	metapath{path: "feedFilters", name: "CustomFeedFilter"},
+	metapath{path: "flexipages", name: "FlexiPage"},
+	metapath{path: "flowDefinitions", name: "FlowDefinition"},
+	metapath{path: "flows", name: "Flow"},
+	metapath{path: "globalPicklists", name: "GlobalPicklist"},
+	metapath{path: "globalValueSets", name: "GlobalValueSet"},
+	metapath{path: "globalValueSetTranslations", name: "GlobalValueSetTranslation"},
+	metapath{path: "groups", name: "Group"},
+	metapath{path: "homePageComponents", name: "HomePageComponent"},
+	metapath{path: "homePageLayouts", name: "HomePageLayout"},
+	metapath{path: "installedPackages", name: "InstalledPackage"},
+	metapath{path: "labels", name: "CustomLabels"},
+	metapath{path: "layouts", name: "Layout"},
+	metapath{path: "LeadConvertSettings", name: "LeadConvertSettings"},
+	metapath{path: "letterhead", name: "Letterhead"},
+	metapath{path: "lightningExperienceThemes", name: "LightningExperienceTheme"},
+	metapath{path: "lwc", name: "LightningComponentBundle", hasFolder: true, onlyFolder: true},
+	metapath{path: "matchingRules", name: "MatchingRules"},
+	metapath{path: "namedCredentials", name: "NamedCredential"},
+	metapath{path: "networks", name: "Network"},
+	metapath{path: "notificationtypes", name: "CustomNotificationType", extension: ".notiftype"},
+	metapath{path: "objects", name: "CustomObject"},
+	metapath{path: "objectTranslations", name: "CustomObjectTranslation"},
+	metapath{path: "pages", name: "ApexPage", metaFile: true},
+	metapath{path: "pathAssistants", name: "PathAssistant"},
+	metapath{path: "permissionsets", name: "PermissionSet"},
+	metapath{path: "platformEventChannelMembers", name: "PlatformEventChannelMember"},
+	metapath{path: "platformEventChannels", name: "PlatformEventChannel"},
+	metapath{path: "postTemplates", name: "PostTemplate"},
+	metapath{path: "profiles", name: "Profile", extension: ".profile"},
+	metapath{path: "profileSessionSettings", name: "ProfileSessionSetting"},
+	metapath{path: "queues", name: "Queue"},
+	metapath{path: "quickActions", name: "QuickAction"},
+	metapath{path: "remoteSiteSettings", name: "RemoteSiteSetting"},
+	metapath{path: "reports", name: "Report", hasFolder: true},
+	metapath{path: "reportTypes", name: "ReportType"},
+	metapath{path: "roles", name: "Role"},
+	metapath{path: "scontrols", name: "Scontrol"},
+	metapath{path: "settings", name: "Settings"},
+	metapath{path: "sharingRules", name: "SharingRules"},
+	metapath{path: "siteDotComSites", name: "SiteDotCom", metaFile: true},
+	metapath{path: "sites", name: "CustomSite"},
+	metapath{path: "standardValueSets", name: "StandardValueSet"},
+	metapath{path: "standardValueSetTranslations", name: "StandardValueSetTranslation"},
+	metapath{path: "staticresources", name: "StaticResource", metaFile: true},
+	metapath{path: "synonymDictionaries", name: "SynonymDictionary"},
+	metapath{path: "tabs", name: "CustomTab"},
+	metapath{path: "translations", name: "Translations"},
+	metapath{path: "triggers", name: "ApexTrigger", metaFile: true},
+	metapath{path: "weblinks", name: "CustomPageWebLink"},
+	metapath{path: "workflows", name: "Workflow"},
+}
+
+// Metadata types by API version
+var describedMetapaths = make(map[string][]metapath)
+
+// Get the metadata types for the current API version, using the cached
+// describeMetadata result if there is one
+func getMetapaths() []metapath {
+	if mps, found := describedMetapaths[apiVersionNumber]; found {
+		return mps
+	}
+	mps := builtinMetapaths
+	if objects, err := loadMetadataTypes(apiVersionNumber); err == nil {
+		mps = metapathsFromDescribe(objects)
+	}
+	describedMetapaths[apiVersionNumber] = mps
+	return mps
+}
+
+func loadMetadataTypes(version string) (objects []DescribeMetadataObject, err error) {
+	data, err := Config.Load("metadatatypes", version)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal([]byte(data), &objects)
+	return
+}
+
+// Cache the metadata types returned by describeMetadata
+func saveMetadataTypes(version string, objects []DescribeMetadataObject) (err error) {
+	data, err := json.Marshal(objects)
+	if err != nil {
+		return
+	}
+	if err = Config.Save("metadatatypes", version, string(data)); err != nil {
+		return
+	}
+	describedMetapaths[version] = metapathsFromDescribe(objects)
+	return
+}
+
+// Describe the metadata types for the current API version if they have not
+// been cached
+func (fm *ForceMetadata) CacheMetadataTypes() (err error) {
+	if _, err = loadMetadataTypes(apiVersionNumber); err == nil {
+		return
+	}
+	_, err = fm.DescribeMetadata()
+	return
+}
+
+// Build the metadata types from a describeMetadata result.  Built-in types
+// whose directories are not in the result are kept so child types and types
+// the org doesn't report can still be found.
+func metapathsFromDescribe(objects []DescribeMetadataObject) (mps []metapath) {
+	described := make(map[string]bool)
+	for _, object := range objects {
+		if object.DirectoryName == "" || described[object.DirectoryName] {
+			continue
+		}
+		mp := metapath{
+			path:      object.DirectoryName,
+			name:      object.XmlName,
+			hasFolder: object.InFolder,
+			metaFile:  object.MetaFile,
+		}
+		if object.Suffix != "" {
+			mp.extension = "." + object.Suffix
+		}
+		if isBundleType(object.XmlName) {
+			mp.hasFolder = true
+			mp.onlyFolder = true
+		}
+		mps = append(mps, mp)
+		described[mp.path] = true
+	}
+	for _, mp := range builtinMetapaths {
+		if !described[mp.path] {
+			mps = append(mps, mp)
+		}
+	}
+	return
+}
+
+func isBundleType(metaName string) bool {
+	for _, t := range bundleTypes {
+		if t == metaName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package lib_test
+
+import (
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metadatatypes", func() {
+	Describe("MetapathsFromDescribe", func() {
+		It("should map directories to the described types", func() {
+			mps := MetapathsFromDescribe([]DescribeMetadataObject{
+				{DirectoryName: "classes", XmlName: "ApexClass", Suffix: "cls", MetaFile: true},
+				{DirectoryName: "lwc", XmlName: "LightningComponentBundle"},
+				{DirectoryName: "reports", XmlName: "Report", Suffix: "report", InFolder: true},
+				{DirectoryName: "platformEventChannels", XmlName: "PlatformEventChannel", Suffix: "platformEventChannel"},
+				{DirectoryName: "profiles", XmlName: "Profile", Suffix: "profile"},
+				{DirectoryName: "profiles", XmlName: "Profile", Suffix: "profile"},
+			})
+			byPath := make(map[string][]Metapath)
+			for _, mp := range mps {
+				byPath[mp.Path] = append(byPath[mp.Path], mp)
+			}
+			for _, expected := range []Metapath{
+				{Path: "classes", Name: "ApexClass", Extension: ".cls", MetaFile: true},
+				{Path: "lwc", Name: "LightningComponentBundle", HasFolder: true, OnlyFolder: true},
+				{Path: "reports", Name: "Report", Extension: ".report", HasFolder: true},
+				{Path: "platformEventChannels", Name: "PlatformEventChannel", Extension: ".platformEventChannel"},
+				{Path: "profiles", Name: "Profile", Extension: ".profile"},
+				{Path: "workflows", Name: "Workflow"},
+			} {
+				Expect(byPath[expected.Path]).To(Equal([]Metapath{expected}))
+			}
+		})
+	})
+})
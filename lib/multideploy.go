@@ -0,0 +1,165 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Deploying the same package to several orgs at once.  Each org is a saved
+// login, and the deploys run concurrently with a bounded number of workers.
+
+type OrgDeployStatus string
+
+const (
+	OrgDeploySucceeded OrgDeployStatus = "Succeeded"
+	OrgDeployFailed    OrgDeployStatus = "Failed"
+	OrgDeploySkipped   OrgDeployStatus = "Skipped"
+)
+
+// The result of deploying to one org
+type OrgDeployResult struct {
+	Login    string
+	Status   OrgDeployStatus
+	Result   ForceCheckDeploymentStatusResult
+	Err      error
+	Duration time.Duration
+}
+
+// Deploy files to each of the logins, running up to workers deploys at once.
+// If stopOnFailure is set, deploys that haven't started when one fails are
+// skipped.  Results are returned in the order of the logins.  An error is
+// returned without deploying if any of the logins can't be loaded.
+func DeployToOrgs(logins []string, files ForceMetadataFiles, opts ForceDeployOptions, workers int, stopOnFailure bool) (results []OrgDeployResult, err error) {
+	forces, err := orgForces(logins)
+	if err != nil {
+		return
+	}
+	results = deployToOrgs(logins, forces, workers, stopOnFailure, func(login string, force *Force) (ForceCheckDeploymentStatusResult, error) {
+		return force.Metadata.Deploy(files, opts)
+	})
+	return
+}
+
+// Load the logins before any deploys start.  Loading a login sets the API
+// version used by the Force created for it, so logins can't be loaded by the
+// deploys running concurrently.
+func orgForces(logins []string) (forces []*Force, err error) {
+	for _, login := range logins {
+		var force *Force
+		if force, err = GetForce(login); err != nil {
+			err = fmt.Errorf("Could not load login %s: %s", login, err.Error())
+			return
+		}
+		forces = append(forces, force)
+	}
+	return
+}
+
+func deployToOrgs(logins []string, forces []*Force, workers int, stopOnFailure bool, deploy func(login string, force *Force) (ForceCheckDeploymentStatusResult, error)) (results []OrgDeployResult) {
+	if workers < 1 {
+		workers = 1
+	}
+	results = make([]OrgDeployResult, len(logins))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := false
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r := &results[i]
+				r.Login = logins[i]
+				mu.Lock()
+				skip := stopOnFailure && failed
+				mu.Unlock()
+				if skip {
+					r.Status = OrgDeploySkipped
+					continue
+				}
+				start := time.Now()
+				r.Result, r.Err = deploy(logins[i], forces[i])
+				r.Duration = time.Since(start)
+				if r.Err == nil {
+					r.Err = deployFailure(r.Result)
+				}
+				r.Status = OrgDeploySucceeded
+				if r.Err != nil {
+					r.Status = OrgDeployFailed
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range logins {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return
+}
+
+// Get the reason a deploy failed, or nil if it succeeded
+func deployFailure(result ForceCheckDeploymentStatusResult) error {
+	switch {
+	case len(result.Details.ComponentFailures) > 0:
+		return errors.New("Some components failed deployment")
+	case len(result.Details.RunTestResult.TestFailures) > 0:
+		return errors.New("Some tests failed")
+	case !result.Success:
+		return fmt.Errorf("Status: %s", result.Status)
+	}
+	return nil
+}
+
+// Display the failures in each org and a summary table of the deploys,
+// returning an error if any deploy failed
+func DisplayOrgDeployResults(results []OrgDeployResult) (err error) {
+	failures := 0
+	for _, r := range results {
+		if r.Status != OrgDeployFailed {
+			continue
+		}
+		failures++
+		fmt.Printf("\n%s:\n", r.Login)
+		for _, problem := range r.Result.Details.ComponentFailures {
+			fmt.Printf("  \"%s\", line %d: %s %s\n", problem.FullName, problem.LineNumber, problem.ProblemType, problem.Problem)
+		}
+		for _, failure := range r.Result.Details.RunTestResult.TestFailures {
+			fmt.Printf("  [FAIL]  %s::%s: %s\n", failure.Name, failure.MethodName, failure.Message)
+		}
+		if len(r.Result.Details.ComponentFailures) == 0 && len(r.Result.Details.RunTestResult.TestFailures) == 0 {
+			fmt.Printf("  %s\n", r.Err.Error())
+		}
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "LOGIN\tSTATUS\tCOMPONENTS\tTESTS\tDURATION\tERROR")
+	for _, r := range results {
+		components, tests, duration, message := "", "", "", ""
+		if r.Status != OrgDeploySkipped {
+			components = fmt.Sprintf("%d/%d", r.Result.NumberComponentsDeployed, r.Result.NumberComponentsTotal)
+			tests = fmt.Sprintf("%d/%d", r.Result.NumberTestsCompleted, r.Result.NumberTestsTotal)
+			duration = r.Duration.Round(time.Second).String()
+		}
+		if r.Err != nil {
+			message = strings.Split(r.Err.Error(), "\n")[0]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Login, r.Status, components, tests, duration, message)
+	}
+	w.Flush()
+
+	if failures > 0 {
+		err = fmt.Errorf("Deploy failed in %d of %d orgs", failures, len(results))
+	}
+	return
+}
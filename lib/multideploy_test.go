@@ -0,0 +1,72 @@
+package lib_test
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Multideploy", func() {
+	Describe("DeployToOrgs", func() {
+		orgs := func(logins []string) (forces []*Force) {
+			for _, login := range logins {
+				forces = append(forces, NewForce(&ForceSession{InstanceUrl: "https://" + login + ".my.salesforce.com"}))
+			}
+			return
+		}
+
+		It("should deploy to every org and report each result", func() {
+			logins := []string{"dev", "uat", "broken", "prod"}
+			var mu sync.Mutex
+			deployed := make(map[string]bool)
+			results := RunOrgDeploys(logins, orgs(logins), 2, false, func(login string, force *Force) (result ForceCheckDeploymentStatusResult, err error) {
+				mu.Lock()
+				deployed[login] = true
+				mu.Unlock()
+				switch login {
+				case "broken":
+					err = errors.New("Could not find login")
+				case "uat":
+					result.Details.ComponentFailures = []ComponentFailure{{FullName: "MyClass", Problem: "Invalid type"}}
+				default:
+					result.Success = true
+				}
+				return
+			})
+			Expect(deployed).To(HaveLen(4))
+			expected := []OrgDeployStatus{OrgDeploySucceeded, OrgDeployFailed, OrgDeployFailed, OrgDeploySucceeded}
+			Expect(results).To(HaveLen(len(logins)))
+			for i, r := range results {
+				Expect(r.Login).To(Equal(logins[i]))
+				Expect(r.Status).To(Equal(expected[i]), r.Login)
+			}
+			Expect(results[1].Err).To(MatchError("Some components failed deployment"))
+		})
+
+		It("should not deploy if a login can't be loaded", func() {
+			results, err := DeployToOrgs([]string{"no-such-login@example.com"}, ForceMetadataFiles{}, ForceDeployOptions{}, 2, false)
+			Expect(err).To(MatchError(HavePrefix("Could not load login no-such-login@example.com")))
+			Expect(results).To(BeEmpty())
+		})
+
+		It("should skip the remaining orgs after a failure", func() {
+			logins := []string{"broken", "uat", "prod"}
+			results := RunOrgDeploys(logins, orgs(logins), 1, true, func(login string, force *Force) (result ForceCheckDeploymentStatusResult, err error) {
+				if login == "broken" {
+					err = errors.New("Could not find login")
+				}
+				result.Success = true
+				return
+			})
+			var statuses []OrgDeployStatus
+			for _, r := range results {
+				statuses = append(statuses, r.Status)
+			}
+			Expect(statuses).To(Equal([]OrgDeployStatus{OrgDeployFailed, OrgDeploySkipped, OrgDeploySkipped}))
+		})
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	. "github.com/ForceCLI/force/error"
@@ -30,108 +31,17 @@ func createPackage() Package {
 	}
 }
 
-type metapath struct {
-	path       string
-	name       string
-	hasFolder  bool
-	onlyFolder bool
-	extension  string
-}
-
-var metapaths = []metapath{
-	metapath{path: "actionLinkGroupTemplates", name: "ActionLinkGroupTemplate"},
-	metapath{path: "analyticSnapshots", name: "AnalyticSnapshot"},
-	metapath{path: "applications", name: "CustomApplication"},
-	metapath{path: "appMenus", name: "AppMenu"},
-	metapath{path: "approvalProcesses", name: "ApprovalProcess"},
-	metapath{path: "assignmentRules", name: "AssignmentRules"},
-	metapath{path: "audience", name: "Audience"},
-	metapath{path: "authproviders", name: "AuthProvider"},
-	metapath{path: "aura", name: "AuraDefinitionBundle", hasFolder: true, onlyFolder: true},
-	metapath{path: "autoResponseRules", name: "AutoResponseRules"},
-	metapath{path: "callCenters", name: "CallCenter"},
-	metapath{path: "cachePartitions", name: "PlatformCachePartition"},
-	metapath{path: "certs", name: "Certificate"},
-	metapath{path: "channelLayouts", name: "ChannelLayout"},
-	metapath{path: "classes", name: "ApexClass"},
-	metapath{path: "communities", name: "Community"},
-	metapath{path: "components", name: "ApexComponent"},
-	metapath{path: "connectedApps", name: "ConnectedApp"},
-	metapath{path: "contentassets", name: "ContentAsset"},
-	metapath{path: "corsWhitelistOrigins", name: "CorsWhitelistOrigin"},
-	metapath{path: "customApplicationComponents", name: "CustomApplicationComponent"},
-	metapath{path: "customMetadata", name: "CustomMetadata"},
-	metapath{path: "customPermissions", name: "CustomPermission"},
-	metapath{path: "dashboards", name: "Dashboard", hasFolder: true},
-	metapath{path: "dataSources", name: "ExternalDataSource"},
-	metapath{path: "datacategorygroups", name: "DataCategoryGroup"},
-	metapath{path: "delegateGroups", name: "DelegateGroup"},
-	metapath{path: "documents", name: "Document", hasFolder: true},
-	metapath{path: "duplicateRules", name: "DuplicateRule"},
-	metapath{path: "EmbeddedServiceConfig", name: "EmbeddedServiceConfig"},
-	metapath{path: "email", name: "EmailTemplate", hasFolder: true},
-	metapath{path: "escalationRules", name: "EscalationRules"},
-	metapath{path: "feedFilters", name: "CustomFeedFilter"},
-	metapath{path: "flexipages", name: "FlexiPage"},
-	metapath{path: "flowDefinitions", name: "FlowDefinition"},
-	metapath{path: "flows", name: "Flow"},
-	metapath{path: "globalPicklists", name: "GlobalPicklist"},
-	metapath{path: "globalValueSets", name: "GlobalValueSet"},
-	metapath{path: "globalValueSetTranslations", name: "GlobalValueSetTranslation"},
-	metapath{path: "groups", name: "Group"},
-	metapath{path: "homePageComponents", name: "HomePageComponent"},
-	metapath{path: "homePageLayouts", name: "HomePageLayout"},
-	metapath{path: "installedPackages", name: "InstalledPackage"},
-	metapath{path: "labels", name: "CustomLabels"},
-	metapath{path: "layouts", name: "Layout"},
-	metapath{path: "LeadConvertSettings", name: "LeadConvertSettings"},
-	metapath{path: "letterhead", name: "Letterhead"},
-	metapath{path: "matchingRules", name: "MatchingRules"},
-	metapath{path: "matchingRules", name: "MatchingRule"},
-	metapath{path: "namedCredentials", name: "NamedCredential"},
-	metapath{path: "networks", name: "Network"},
-	metapath{path: "objects", name: "CustomObject"},
-	metapath{path: "objectTranslations", name: "CustomObjectTranslation"},
-	metapath{path: "pages", name: "ApexPage"},
-	metapath{path: "pathAssistants", name: "PathAssistant"},
-	metapath{path: "permissionsets", name: "PermissionSet"},
-	metapath{path: "postTemplates", name: "PostTemplate"},
-	metapath{path: "profiles", name: "Profile", extension: ".profile"},
-	metapath{path: "postTemplates", name: "PostTemplate"},
-	metapath{path: "postTemplates", name: "PostTemplate"},
-	metapath{path: "profiles", name: "Profile"},
-	metapath{path: "profileSessionSettings", name: "ProfileSessionSetting"},
-	metapath{path: "queues", name: "Queue"},
-	metapath{path: "quickActions", name: "QuickAction"},
-	metapath{path: "remoteSiteSettings", name: "RemoteSiteSetting"},
-	metapath{path: "reports", name: "Report", hasFolder: true},
-	metapath{path: "reportTypes", name: "ReportType"},
-	metapath{path: "roles", name: "Role"},
-	metapath{path: "scontrols", name: "Scontrol"},
-	metapath{path: "settings", name: "Settings"},
-	metapath{path: "sharingRules", name: "SharingRules"},
-	metapath{path: "siteDotComSites", name: "SiteDotCom"},
-	metapath{path: "sites", name: "CustomSite"},
-	metapath{path: "standardValueSets", name: "StandardValueSet"},
-	metapath{path: "staticresources", name: "StaticResource"},
-	metapath{path: "synonymDictionaries", name: "SynonymDictionary"},
-	metapath{path: "tabs", name: "CustomTab"},
-	metapath{path: "translations", name: "Translations"},
-	metapath{path: "triggers", name: "ApexTrigger"},
-	metapath{path: "weblinks", name: "CustomPageWebLink"},
-	metapath{path: "workflows", name: "Workflow"},
-	metapath{path: "cspTrustedSites", name: "CspTrustedSite"},
-}
-
 type PackageBuilder struct {
-	IsPush   bool
-	Metadata map[string]MetaType
-	Files    ForceMetadataFiles
+	IsPush             bool
+	Metadata           map[string]MetaType
+	DestructiveChanges map[string]MetaType
+	Files              ForceMetadataFiles
 }
 
 func NewPushBuilder() PackageBuilder {
 	pb := PackageBuilder{IsPush: true}
 	pb.Metadata = make(map[string]MetaType)
+	pb.DestructiveChanges = make(map[string]MetaType)
 	pb.Files = make(ForceMetadataFiles)
 
 	return pb
@@ -140,6 +50,7 @@ func NewPushBuilder() PackageBuilder {
 func NewFetchBuilder() PackageBuilder {
 	pb := PackageBuilder{IsPush: false}
 	pb.Metadata = make(map[string]MetaType)
+	pb.DestructiveChanges = make(map[string]MetaType)
 	pb.Files = make(ForceMetadataFiles)
 
 	return pb
@@ -147,10 +58,28 @@ func NewFetchBuilder() PackageBuilder {
 
 // Build and return package.xml
 func (pb PackageBuilder) PackageXml() []byte {
+	return packageXml(pb.Metadata)
+}
+
+// Build and return destructiveChanges.xml for the components that have been
+// marked as deleted
+func (pb PackageBuilder) DestructiveChangesXml() []byte {
+	return packageXml(pb.DestructiveChanges)
+}
+
+func packageXml(metadata map[string]MetaType) []byte {
 	p := createPackage()
 
-	for _, metaType := range pb.Metadata {
-		p.Types = append(p.Types, metaType)
+	var names []string
+	for name := range metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		metaType := metadata[name]
+		members := append([]string{}, metaType.Members...)
+		sort.Strings(members)
+		p.Types = append(p.Types, MetaType{Name: metaType.Name, Members: members})
 	}
 
 	byteXml, _ := xml.MarshalIndent(p, "", "    ")
@@ -162,9 +91,25 @@ func (pb PackageBuilder) PackageXml() []byte {
 }
 
 // Returns the full ForceMetadataFiles container
-func (pb *PackageBuilder) ForceMetadataFiles() ForceMetadataFiles {
+func (pb *PackageBuilder) ForceMetadataFiles() (files ForceMetadataFiles, err error) {
+	// Files may have been added from a project in the source format
+	files, err = ConvertToMetadataFormat(pb.Files)
+	if err != nil {
+		return
+	}
+	pb.Files = files
 	pb.Files["package.xml"] = pb.PackageXml()
-	return pb.Files
+	if len(pb.DestructiveChanges) > 0 {
+		// A destructiveChanges.xml added from a file takes precedence, so
+		// deleted components are removed after the deploy instead
+		name := "destructiveChanges.xml"
+		if _, found := pb.Files[name]; found {
+			name = "destructiveChangesPost.xml"
+		}
+		pb.addDestructiveChangesData(name, pb.DestructiveChangesXml())
+	}
+	files = pb.Files
+	return
 }
 
 // Returns the source file path for a given metadata file path.
@@ -199,7 +144,7 @@ func (pb *PackageBuilder) AddFile(fpath string) (fname string, err error) {
 
 	fpath = MetaPathToSourcePath(fpath)
 	metaName, fname := getMetaTypeFromPath(fpath)
-	if !isDestructiveChanges && !strings.HasSuffix(fpath, "-meta.xml") {
+	if !isDestructiveChanges && (!strings.HasSuffix(fpath, "-meta.xml") || isSourceMetaFile(fpath)) {
 		pb.AddMetaToPackage(metaName, fname)
 	}
 
@@ -215,8 +160,15 @@ func (pb *PackageBuilder) AddFile(fpath string) (fname string, err error) {
 	return
 }
 
+// Options for adding a directory to a package
+type AddDirectoryOptions struct {
+	// Only add the files of components, skipping package.xml and hidden
+	// files such as .DS_Store, and not adding the directories themselves
+	SkipNonComponents bool
+}
+
 //AddDirectory Recursively add files contained in provided directory
-func (pb *PackageBuilder) AddDirectory(fpath string) (namePaths map[string]string, badPaths []string, err error) {
+func (pb *PackageBuilder) AddDirectory(fpath string, opts AddDirectoryOptions) (namePaths map[string]string, badPaths []string, err error) {
 	namePaths = make(map[string]string)
 
 	files, err := ioutil.ReadDir(fpath)
@@ -227,8 +179,14 @@ func (pb *PackageBuilder) AddDirectory(fpath string) (namePaths map[string]strin
 
 	for _, f := range files {
 		dirOrFilePath := fpath + "/" + f.Name()
+		if isLocalDevelopmentFile(dirOrFilePath, f) {
+			continue
+		}
+		if opts.SkipNonComponents && (strings.HasPrefix(f.Name(), ".") || f.Name() == "package.xml") {
+			continue
+		}
 		if f.IsDir() {
-			dirNamePaths, dirBadPath, err := pb.AddDirectory(dirOrFilePath)
+			dirNamePaths, dirBadPath, err := pb.AddDirectory(dirOrFilePath, opts)
 			if err != nil {
 				badPaths = append(badPaths, dirBadPath...)
 			} else {
@@ -236,6 +194,9 @@ func (pb *PackageBuilder) AddDirectory(fpath string) (namePaths map[string]strin
 					namePaths[dirContentName] = dirContentPath
 				}
 			}
+			if opts.SkipNonComponents {
+				continue
+			}
 		}
 
 		name, err := pb.AddFile(dirOrFilePath)
@@ -249,20 +210,31 @@ func (pb *PackageBuilder) AddDirectory(fpath string) (namePaths map[string]strin
 	return
 }
 
+// Determine whether a file is only used for local development of bundles,
+// such as Jest tests in Lightning Web Components or ESLint configuration in
+// the lwc directory, and should not be deployed
+func isLocalDevelopmentFile(fpath string, f os.FileInfo) bool {
+	if f.IsDir() {
+		return f.Name() == "__tests__"
+	}
+	parentName := filepath.Base(filepath.Dir(fpath))
+	for _, mp := range getMetapaths() {
+		if mp.onlyFolder && mp.path == parentName {
+			return true
+		}
+	}
+	return false
+}
+
 // Adds the file to a temp directory for deploy
 func (pb *PackageBuilder) addFileToWorkingDir(metaName string, fpath string) (err error) {
 	// Get relative dir from source
-	srcDir := filepath.Dir(filepath.Dir(fpath))
-	for _, mp := range metapaths {
-		if metaName == mp.name && mp.hasFolder {
-			srcDir = filepath.Dir(srcDir)
-		}
-	}
+	srcDir := metadataBaseDir(metaName, fpath)
 	frel, _ := filepath.Rel(srcDir, fpath)
 
 	// Try to find meta file
 	hasMeta := true
-	fmeta := fpath + "-meta.xml"
+	fmeta := metaFileForPath(metaName, fpath)
 	fmetarel := ""
 	if _, err = os.Stat(fmeta); err != nil {
 		if os.IsNotExist(err) {
@@ -291,6 +263,37 @@ func (pb *PackageBuilder) addFileToWorkingDir(metaName string, fpath string) (er
 	return
 }
 
+// Gets the directory that contains the metadata type directories for a file
+func metadataBaseDir(metaName string, fpath string) string {
+	parentDir := filepath.Dir(fpath)
+	if _, _, found := getSourceMetaForPath(strings.TrimSuffix(fpath, "-meta.xml")); found {
+		if filepath.Base(filepath.Dir(parentDir)) == "objects" {
+			return filepath.Dir(filepath.Dir(parentDir))
+		}
+		return filepath.Dir(filepath.Dir(filepath.Dir(parentDir)))
+	}
+	srcDir := filepath.Dir(parentDir)
+	for _, mp := range getMetapaths() {
+		if metaName == mp.name && mp.hasFolder && filepath.Base(parentDir) != mp.path {
+			srcDir = filepath.Dir(srcDir)
+		}
+	}
+	return srcDir
+}
+
+// Gets the path of the -meta.xml file for a content file.  Static resources
+// in the source format may have an extension matching their content type,
+// e.g. jquery.js and jquery.resource-meta.xml.
+func metaFileForPath(metaName string, fpath string) string {
+	fmeta := fpath + "-meta.xml"
+	if metaName == "StaticResource" && filepath.Ext(fpath) != ".resource" {
+		if _, err := os.Stat(fmeta); os.IsNotExist(err) {
+			fmeta = strings.TrimSuffix(fpath, filepath.Ext(fpath)) + ".resource-meta.xml"
+		}
+	}
+	return fmeta
+}
+
 func (pb *PackageBuilder) addDestructiveChanges(fpath string) (err error) {
 	fdata, err := ioutil.ReadFile(fpath)
 	if err != nil {
@@ -298,11 +301,42 @@ func (pb *PackageBuilder) addDestructiveChanges(fpath string) (err error) {
 	}
 
 	frel, _ := filepath.Rel(filepath.Dir(fpath), fpath)
-	pb.Files[frel] = fdata
+	pb.addDestructiveChangesData(frel, fdata)
 
 	return
 }
 
+func (pb *PackageBuilder) addDestructiveChangesData(name string, data []byte) {
+	pb.Files[name] = data
+}
+
+// Add a file that has been deleted locally to destructiveChanges.xml.  The
+// file does not need to exist.
+func (pb *PackageBuilder) AddDeletedFile(fpath string) (fname string, err error) {
+	fpath, err = filepath.Abs(fpath)
+	if err != nil {
+		return
+	}
+	fpath = strings.TrimSuffix(fpath, "-meta.xml")
+	metaName, fileName := getMetaForPath(fpath)
+	fname = componentMember(metaName, fileName)
+	pb.AddDestructiveMetaToPackage(metaName, fname)
+	return
+}
+
+// Adds a metadata name to the pending destructiveChanges.xml
+func (pb *PackageBuilder) AddDestructiveMetaToPackage(metaName string, name string) {
+	mt := pb.DestructiveChanges[metaName]
+	if mt.Name == "" {
+		mt.Name = metaName
+	}
+
+	if !pb.contains(mt.Members, name) {
+		mt.Members = append(mt.Members, name)
+		pb.DestructiveChanges[metaName] = mt
+	}
+}
+
 func (pb *PackageBuilder) contains(members []string, name string) bool {
 	for _, a := range members {
 		if a == name {
@@ -336,19 +370,27 @@ func getMetaTypeFromPath(fpath string) (metaName string, name string) {
 	}
 
 	// Get the metadata type and name for the file
-	metaName, fileName := getMetaForPath(fpath)
-	name = strings.TrimSuffix(fileName, filepath.Ext(fileName))
-	//name = strings.TrimSuffix(name, filepath.Ext(name))
+	metaName, fileName := getMetaForPath(strings.TrimSuffix(fpath, "-meta.xml"))
+	name = componentMember(metaName, fileName)
 	return
 }
 
+// Gets the name of the component a file is for.  Documents are named with
+// their extension.
+func componentMember(metaName string, fileName string) string {
+	if metaName == "Document" {
+		return fileName
+	}
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName))
+}
+
 func findMetapathForFile(file string) (path metapath) {
 	parentDir := filepath.Dir(file)
 	parentName := filepath.Base(parentDir)
 	grandparentName := filepath.Base(filepath.Dir(parentDir))
 	fileExtension := filepath.Ext(file)
 
-	for _, mp := range metapaths {
+	for _, mp := range getMetapaths() {
 		if mp.hasFolder && grandparentName == mp.path {
 			return mp
 		}
@@ -358,7 +400,7 @@ func findMetapathForFile(file string) (path metapath) {
 	}
 
 	// Hmm, maybe we can use the extension to determine the type
-	for _, mp := range metapaths {
+	for _, mp := range getMetapaths() {
 		if mp.extension == fileExtension {
 			return mp
 		}
@@ -373,7 +415,11 @@ func getMetaForPath(path string) (metaName string, objectName string) {
 	grandparentName := filepath.Base(filepath.Dir(parentDir))
 	fileName := filepath.Base(path)
 
-	for _, mp := range metapaths {
+	if metaName, objectName, found := getSourceMetaForPath(path); found {
+		return metaName, objectName
+	}
+
+	for _, mp := range getMetapaths() {
 		if mp.hasFolder && grandparentName == mp.path {
 			metaName = mp.name
 			if mp.onlyFolder {
@@ -157,4 +157,102 @@ var _ = Describe("Packagebuilder", func() {
 			})
 		})
 	})
+
+	Describe("getMetaForPath", func() {
+		var (
+			pb      PackageBuilder
+			tempDir string
+		)
+
+		BeforeEach(func() {
+			pb = NewPushBuilder()
+			tempDir, _ = ioutil.TempDir("", "packagebuilder-test")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should find types that have no file extension mapping", func() {
+			os.MkdirAll(tempDir+"/src/lwc/myCmp", 0755)
+			os.MkdirAll(tempDir+"/src/platformEventChannels", 0755)
+			ioutil.WriteFile(tempDir+"/src/lwc/myCmp/myCmp.js", []byte(""), 0644)
+			ioutil.WriteFile(tempDir+"/src/platformEventChannels/Changes.platformEventChannel", []byte(""), 0644)
+
+			pb.AddFile(tempDir + "/src/lwc/myCmp/myCmp.js")
+			pb.AddFile(tempDir + "/src/platformEventChannels/Changes.platformEventChannel")
+			Expect(pb.Metadata["LightningComponentBundle"].Members).To(Equal([]string{"myCmp"}))
+			Expect(pb.Metadata["PlatformEventChannel"].Members).To(Equal([]string{"Changes"}))
+			Expect(pb.Files).To(HaveKey("lwc/myCmp/myCmp.js"))
+		})
+	})
+
+	Describe("AddDirectory", func() {
+		var (
+			pb      PackageBuilder
+			tempDir string
+		)
+
+		BeforeEach(func() {
+			pb = NewPushBuilder()
+			tempDir, _ = ioutil.TempDir("", "packagebuilder-test")
+			os.MkdirAll(tempDir+"/src/lwc/myCmp/__tests__", 0755)
+			ioutil.WriteFile(tempDir+"/src/lwc/jsconfig.json", []byte("{}"), 0644)
+			ioutil.WriteFile(tempDir+"/src/lwc/myCmp/myCmp.js", []byte(""), 0644)
+			ioutil.WriteFile(tempDir+"/src/lwc/myCmp/myCmp.html", []byte("<template></template>"), 0644)
+			ioutil.WriteFile(tempDir+"/src/lwc/myCmp/myCmp.js-meta.xml", []byte("<LightningComponentBundle/>"), 0644)
+			ioutil.WriteFile(tempDir+"/src/lwc/myCmp/__tests__/myCmp.test.js", []byte(""), 0644)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should not deploy files used for local development of Lightning Web Components", func() {
+			pb.AddDirectory(tempDir+"/src/lwc", AddDirectoryOptions{})
+			Expect(pb.Metadata).To(HaveLen(1))
+			Expect(pb.Metadata["LightningComponentBundle"].Members).To(Equal([]string{"myCmp"}))
+			Expect(pb.Files).To(HaveLen(3))
+			Expect(pb.Files).To(HaveKey("lwc/myCmp/myCmp.js"))
+			Expect(pb.Files).To(HaveKey("lwc/myCmp/myCmp.html"))
+			Expect(pb.Files).To(HaveKey("lwc/myCmp/myCmp.js-meta.xml"))
+		})
+	})
+
+	Describe("AddDeletedFile", func() {
+		var pb PackageBuilder
+
+		BeforeEach(func() {
+			pb = NewPushBuilder()
+		})
+
+		It("should add the component to destructiveChanges.xml", func() {
+			_, err := pb.AddDeletedFile("/tmp/no-such-dir/src/classes/Gone.cls")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pb.DestructiveChanges).To(HaveKey("ApexClass"))
+			Expect(pb.DestructiveChanges["ApexClass"].Members).To(Equal([]string{"Gone"}))
+			Expect(pb.Metadata).To(BeEmpty())
+		})
+		It("should treat a -meta.xml file as its component", func() {
+			pb.AddDeletedFile("/tmp/no-such-dir/src/classes/Gone.cls")
+			pb.AddDeletedFile("/tmp/no-such-dir/src/classes/Gone.cls-meta.xml")
+			Expect(pb.DestructiveChanges["ApexClass"].Members).To(Equal([]string{"Gone"}))
+		})
+		It("should use the bundle name for aura files", func() {
+			pb.AddDeletedFile("/tmp/no-such-dir/src/aura/myCmp/myCmp.cmp")
+			Expect(pb.DestructiveChanges["AuraDefinitionBundle"].Members).To(Equal([]string{"myCmp"}))
+		})
+		It("should keep the extension of documents", func() {
+			pb.AddDeletedFile("/tmp/no-such-dir/src/documents/Images/logo.png")
+			pb.AddDeletedFile("/tmp/no-such-dir/src/documents/Images/logo.png-meta.xml")
+			Expect(pb.DestructiveChanges["Document"].Members).To(Equal([]string{"Images/logo.png"}))
+		})
+		It("should include destructiveChanges.xml in the package files", func() {
+			pb.AddDeletedFile("/tmp/no-such-dir/src/classes/Gone.cls")
+			files, err := pb.ForceMetadataFiles()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveKey("package.xml"))
+			Expect(string(files["destructiveChanges.xml"])).To(ContainSubstring("<members>Gone</members>"))
+		})
+	})
 })
@@ -4,6 +4,7 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"html"
 	"strings"
 )
 
@@ -123,3 +124,197 @@ func (partner *ForcePartner) soapExecute(action, query string) (response []byte,
 	}
 	return
 }
+
+// Maximum number of records that can be passed to a single partner API call
+const PartnerBatchSize = 200
+
+type ForcePartnerError struct {
+	Fields     []string `xml:"fields"`
+	Message    string   `xml:"message"`
+	StatusCode string   `xml:"statusCode"`
+}
+
+type ForcePartnerResult struct {
+	Id      string              `xml:"id"`
+	Success bool                `xml:"success"`
+	Errors  []ForcePartnerError `xml:"errors"`
+}
+
+func (result ForcePartnerResult) Error() string {
+	var messages []string
+	for _, e := range result.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", e.StatusCode, e.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Restore records from the recycle bin
+func (partner *ForcePartner) Undelete(ids []string) (results []ForcePartnerResult, err error) {
+	return partner.executeByIds("undelete", ids)
+}
+
+// Permanently delete records from the recycle bin
+func (partner *ForcePartner) EmptyRecycleBin(ids []string) (results []ForcePartnerResult, err error) {
+	return partner.executeByIds("emptyRecycleBin", ids)
+}
+
+// Call a partner API action that takes a list of ids, splitting the ids into
+// batches of PartnerBatchSize.  A result is returned for each id.
+func (partner *ForcePartner) executeByIds(action string, ids []string) (results []ForcePartnerResult, err error) {
+	for start := 0; start < len(ids); start += PartnerBatchSize {
+		end := start + PartnerBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		soap := ""
+		for _, id := range ids[start:end] {
+			soap += fmt.Sprintf("<ids>%s</ids>", html.EscapeString(id))
+		}
+		var body []byte
+		body, err = partner.SoapExecuteCore(action, soap)
+		if err != nil {
+			return
+		}
+		var batch []ForcePartnerResult
+		if batch, err = parsePartnerResults(body); err != nil {
+			return
+		}
+		results = append(results, batch...)
+	}
+	return
+}
+
+// Parse the list of results from a partner API response, whatever the name
+// of the response element.
+func parsePartnerResults(body []byte) (results []ForcePartnerResult, err error) {
+	var response struct {
+		Body struct {
+			Response struct {
+				Results []ForcePartnerResult `xml:"result"`
+			} `xml:",any"`
+		} `xml:"Body"`
+	}
+	if err = xml.Unmarshal(body, &response); err != nil {
+		return
+	}
+	results = response.Body.Response.Results
+	return
+}
+
+type ForceMergeRequest struct {
+	MasterId     string
+	DuplicateIds []string
+}
+
+type ForceMergeResult struct {
+	Id                string              `xml:"id"`
+	Success           bool                `xml:"success"`
+	Errors            []ForcePartnerError `xml:"errors"`
+	MergedRecordIds   []string            `xml:"mergedRecordIds"`
+	UpdatedRelatedIds []string            `xml:"updatedRelatedIds"`
+}
+
+type LeadConvert struct {
+	XMLName                xml.Name `xml:"leadConverts"`
+	AccountId              string   `xml:"accountId,omitempty"`
+	ContactId              string   `xml:"contactId,omitempty"`
+	ConvertedStatus        string   `xml:"convertedStatus"`
+	DoNotCreateOpportunity bool     `xml:"doNotCreateOpportunity"`
+	LeadId                 string   `xml:"leadId"`
+	OpportunityName        string   `xml:"opportunityName,omitempty"`
+	OverwriteLeadSource    bool     `xml:"overwriteLeadSource"`
+	OwnerId                string   `xml:"ownerId,omitempty"`
+	SendNotificationEmail  bool     `xml:"sendNotificationEmail"`
+}
+
+type LeadConvertResult struct {
+	AccountId     string              `xml:"accountId"`
+	ContactId     string              `xml:"contactId"`
+	LeadId        string              `xml:"leadId"`
+	OpportunityId string              `xml:"opportunityId"`
+	Success       bool                `xml:"success"`
+	Errors        []ForcePartnerError `xml:"errors"`
+}
+
+const sobjectPartnerNamespace = "urn:sobject.partner.soap.sforce.com"
+
+// Merge up to two duplicate records into each master record.  Requests are
+// sent in batches of up to PartnerBatchSize, with each master record
+// appearing at most once per batch.
+func (partner *ForcePartner) Merge(object string, requests []ForceMergeRequest) (results []ForceMergeResult, err error) {
+	for _, batch := range batchMergeRequests(requests) {
+		var body []byte
+		body, err = partner.SoapExecuteCore("merge", mergeRequestsXml(object, batch))
+		if err != nil {
+			return
+		}
+		var response struct {
+			Results []ForceMergeResult `xml:"Body>mergeResponse>result"`
+		}
+		if err = xml.Unmarshal(body, &response); err != nil {
+			return
+		}
+		results = append(results, response.Results...)
+	}
+	return
+}
+
+// The merge requests for a batch.  The master record's fields are in the
+// sObject namespace rather than the partner namespace of the request.
+func mergeRequestsXml(object string, batch []ForceMergeRequest) (soap string) {
+	for _, request := range batch {
+		ids := ""
+		for _, id := range request.DuplicateIds {
+			ids += fmt.Sprintf("<recordToMergeIds>%s</recordToMergeIds>", html.EscapeString(id))
+		}
+		soap += fmt.Sprintf(`<request><masterRecord><type xmlns="%s">%s</type><Id xmlns="%s">%s</Id></masterRecord>%s</request>`,
+			sobjectPartnerNamespace, html.EscapeString(object), sobjectPartnerNamespace, html.EscapeString(request.MasterId), ids)
+	}
+	return
+}
+
+func batchMergeRequests(requests []ForceMergeRequest) (batches [][]ForceMergeRequest) {
+	var batch []ForceMergeRequest
+	masters := make(map[string]bool)
+	for _, request := range requests {
+		if len(batch) == PartnerBatchSize || masters[request.MasterId] {
+			batches = append(batches, batch)
+			batch = nil
+			masters = make(map[string]bool)
+		}
+		batch = append(batch, request)
+		masters[request.MasterId] = true
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return
+}
+
+// Convert leads, in batches of up to PartnerBatchSize
+func (partner *ForcePartner) ConvertLead(converts []LeadConvert) (results []LeadConvertResult, err error) {
+	for start := 0; start < len(converts); start += PartnerBatchSize {
+		end := start + PartnerBatchSize
+		if end > len(converts) {
+			end = len(converts)
+		}
+		var soap []byte
+		soap, err = xml.Marshal(converts[start:end])
+		if err != nil {
+			return
+		}
+		var body []byte
+		body, err = partner.SoapExecuteCore("convertLead", string(soap))
+		if err != nil {
+			return
+		}
+		var response struct {
+			Results []LeadConvertResult `xml:"Body>convertLeadResponse>result"`
+		}
+		if err = xml.Unmarshal(body, &response); err != nil {
+			return
+		}
+		results = append(results, response.Results...)
+	}
+	return
+}
@@ -0,0 +1,40 @@
+package lib_test
+
+import (
+	"encoding/xml"
+	"strings"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Partner", func() {
+	Describe("Merge", func() {
+		It("should put the sObject fields in the sObject namespace", func() {
+			soap := NewSoap("https://example.my.salesforce.com", "urn:partner.soap.sforce.com", "token")
+			envelope := soap.Envelope("merge", MergeRequestsXml("Account", []ForceMergeRequest{
+				{MasterId: "001000000000001AAA", DuplicateIds: []string{"001000000000002AAA"}},
+			}))
+
+			namespaces := make(map[string]string)
+			decoder := xml.NewDecoder(strings.NewReader(envelope))
+			for {
+				token, err := decoder.Token()
+				if err != nil {
+					break
+				}
+				if start, ok := token.(xml.StartElement); ok {
+					namespaces[start.Name.Local] = start.Name.Space
+				}
+			}
+			Expect(namespaces).To(HaveKeyWithValue("merge", "urn:partner.soap.sforce.com"))
+			Expect(namespaces).To(HaveKeyWithValue("request", "urn:partner.soap.sforce.com"))
+			Expect(namespaces).To(HaveKeyWithValue("masterRecord", "urn:partner.soap.sforce.com"))
+			Expect(namespaces).To(HaveKeyWithValue("recordToMergeIds", "urn:partner.soap.sforce.com"))
+			Expect(namespaces).To(HaveKeyWithValue("type", "urn:sobject.partner.soap.sforce.com"))
+			Expect(namespaces).To(HaveKeyWithValue("Id", "urn:sobject.partner.soap.sforce.com"))
+		})
+	})
+})
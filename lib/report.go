@@ -0,0 +1,183 @@
+package lib
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Test results in a form that can be written as a JUnit XML or JSON report
+// for CI systems.  Deploy component failures are reported as failed test
+// cases alongside Apex test results.
+type TestReport struct {
+	XMLName xml.Name          `xml:"testsuites" json:"-"`
+	Suites  []TestReportSuite `xml:"testsuite" json:"suites"`
+}
+
+type TestReportSuite struct {
+	Name     string           `xml:"name,attr" json:"name"`
+	Tests    int              `xml:"tests,attr" json:"tests"`
+	Failures int              `xml:"failures,attr" json:"failures"`
+	Time     float64          `xml:"time,attr" json:"time"`
+	Cases    []TestReportCase `xml:"testcase" json:"cases"`
+}
+
+type TestReportCase struct {
+	ClassName string             `xml:"classname,attr" json:"className"`
+	Name      string             `xml:"name,attr" json:"name"`
+	Time      float64            `xml:"time,attr" json:"time"`
+	Failure   *TestReportFailure `xml:"failure,omitempty" json:"failure,omitempty"`
+}
+
+type TestReportFailure struct {
+	Message    string `xml:"message,attr" json:"message"`
+	Type       string `xml:"type,attr,omitempty" json:"type,omitempty"`
+	StackTrace string `xml:",chardata" json:"stackTrace,omitempty"`
+}
+
+func (suite *TestReportSuite) add(c TestReportCase) {
+	suite.Cases = append(suite.Cases, c)
+	suite.Tests++
+	suite.Time += c.Time
+	if c.Failure != nil {
+		suite.Failures++
+	}
+}
+
+// Build a report from the result of a deploy.  Test times are reported by
+// the Metadata API in milliseconds.
+func NewDeployReport(result ForceCheckDeploymentStatusResult) (report TestReport) {
+	components := TestReportSuite{Name: "Deploy"}
+	for _, success := range result.Details.ComponentSuccesses {
+		if success.FullName == "package.xml" {
+			continue
+		}
+		components.add(TestReportCase{ClassName: success.FileName, Name: success.FullName})
+	}
+	for _, problem := range result.Details.ComponentFailures {
+		message := problem.Problem
+		if problem.LineNumber > 0 {
+			message = fmt.Sprintf("%s, line %d: %s", problem.FileName, problem.LineNumber, problem.Problem)
+		}
+		components.add(TestReportCase{
+			ClassName: problem.FileName,
+			Name:      problem.FullName,
+			Failure: &TestReportFailure{
+				Message: message,
+				Type:    problem.ProblemType,
+			},
+		})
+	}
+	report.Suites = append(report.Suites, components)
+
+	tests := TestReportSuite{Name: "Apex Tests"}
+	for _, success := range result.Details.RunTestResult.TestSuccesses {
+		tests.add(TestReportCase{
+			ClassName: success.Name,
+			Name:      success.MethodName,
+			Time:      float64(success.Time) / 1000,
+		})
+	}
+	for _, failure := range result.Details.RunTestResult.TestFailures {
+		tests.add(TestReportCase{
+			ClassName: failure.Name,
+			Name:      failure.MethodName,
+			Time:      float64(failure.Time) / 1000,
+			Failure: &TestReportFailure{
+				Message:    failure.Message,
+				StackTrace: failure.StackTrace,
+			},
+		})
+	}
+	if tests.Tests > 0 {
+		report.Suites = append(report.Suites, tests)
+	}
+	return
+}
+
+// Build a report from the result of an Apex test run
+func NewTestCoverageReport(output TestCoverage) (report TestReport) {
+	tests := TestReportSuite{Name: "Apex Tests"}
+	for i := range output.SMethodNames {
+		success := TestReportCase{ClassName: output.SClassNames[i], Name: output.SMethodNames[i]}
+		if i < len(output.STime) {
+			success.Time = output.STime[i] / 1000
+		}
+		tests.add(success)
+	}
+	for i := range output.FMethodNames {
+		failure := TestReportFailure{Message: output.FMessage[i]}
+		if i < len(output.FStackTrace) {
+			failure.StackTrace = output.FStackTrace[i]
+		}
+		c := TestReportCase{
+			ClassName: output.FClassNames[i],
+			Name:      output.FMethodNames[i],
+			Failure:   &failure,
+		}
+		if i < len(output.FTime) {
+			c.Time = output.FTime[i] / 1000
+		}
+		tests.add(c)
+	}
+	report.Suites = append(report.Suites, tests)
+	return
+}
+
+func (report TestReport) JUnit() ([]byte, error) {
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func (report TestReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(report, "", "  ")
+}
+
+// Parse a report specification of the form junit=path.xml,json=path.json
+// into a map of format to path.
+func ParseReportSpec(spec string) (reports map[string]string, err error) {
+	reports = make(map[string]string)
+	for _, report := range strings.Split(spec, ",") {
+		parts := strings.SplitN(report, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			err = fmt.Errorf("Invalid report %q.  Use format=path, e.g. junit=results.xml", report)
+			return
+		}
+		format := strings.ToLower(parts[0])
+		if format != "junit" && format != "json" {
+			err = fmt.Errorf("Unknown report format %q.  Use junit or json.", parts[0])
+			return
+		}
+		reports[format] = parts[1]
+	}
+	return
+}
+
+// Write the report in each format given in the specification
+func (report TestReport) Write(spec string) (err error) {
+	reports, err := ParseReportSpec(spec)
+	if err != nil {
+		return
+	}
+	for format, path := range reports {
+		var data []byte
+		switch format {
+		case "junit":
+			data, err = report.JUnit()
+		case "json":
+			data, err = report.JSON()
+		}
+		if err != nil {
+			return
+		}
+		if err = ioutil.WriteFile(path, data, 0644); err != nil {
+			return
+		}
+	}
+	return
+}
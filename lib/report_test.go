@@ -0,0 +1,97 @@
+package lib_test
+
+import (
+	"encoding/xml"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Report", func() {
+	Describe("ParseReportSpec", func() {
+		It("should parse multiple reports", func() {
+			reports, err := ParseReportSpec("junit=results.xml,json=results.json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reports).To(Equal(map[string]string{"junit": "results.xml", "json": "results.json"}))
+		})
+		It("should reject unknown formats", func() {
+			_, err := ParseReportSpec("html=results.html")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should reject reports without a path", func() {
+			_, err := ParseReportSpec("junit")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("NewDeployReport", func() {
+		var result ForceCheckDeploymentStatusResult
+
+		BeforeEach(func() {
+			result = ForceCheckDeploymentStatusResult{}
+			result.Details.ComponentSuccesses = []ComponentSuccess{
+				{FullName: "package.xml", FileName: "package.xml"},
+				{FullName: "Good", FileName: "classes/Good.cls"},
+			}
+			result.Details.ComponentFailures = []ComponentFailure{
+				{FullName: "Bad", FileName: "classes/Bad.cls", LineNumber: 3, Problem: "Missing ';'", ProblemType: "Error"},
+			}
+			result.Details.RunTestResult.TestSuccesses = []TestSuccess{
+				{Name: "GoodTest", MethodName: "testIt", Time: 1500},
+			}
+			result.Details.RunTestResult.TestFailures = []TestFailure{
+				{Name: "BadTest", MethodName: "testIt", Message: "Assertion Failed", StackTrace: "Class.BadTest.testIt: line 5"},
+			}
+		})
+
+		It("should report component failures as failed test cases", func() {
+			report := NewDeployReport(result)
+			Expect(report.Suites[0].Tests).To(Equal(2))
+			Expect(report.Suites[0].Failures).To(Equal(1))
+			Expect(report.Suites[0].Cases[1].Failure.Message).To(Equal("classes/Bad.cls, line 3: Missing ';'"))
+		})
+
+		It("should report test times in seconds", func() {
+			report := NewDeployReport(result)
+			Expect(report.Suites[1].Cases[0].Time).To(Equal(1.5))
+		})
+
+		It("should include stack traces in JUnit XML", func() {
+			junit, err := NewDeployReport(result).JUnit()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(junit)).To(ContainSubstring(`<testsuite name="Apex Tests" tests="2" failures="1" time="1.5">`))
+			Expect(string(junit)).To(ContainSubstring(`<failure message="Assertion Failed">Class.BadTest.testIt: line 5</failure>`))
+		})
+	})
+
+	Describe("NewTestCoverageReport", func() {
+		var output TestCoverage
+
+		BeforeEach(func() {
+			output = TestCoverage{}
+			err := xml.Unmarshal([]byte(`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"><soapenv:Body>
+<runTestsResponse><result>
+<failures><message>Assertion Failed</message><methodName>testIt</methodName><name>BadTest</name><stackTrace>Class.BadTest.testIt: line 5</stackTrace><time>250.0</time></failures>
+<numFailures>1</numFailures><numTestsRun>2</numTestsRun>
+<successes><methodName>testIt</methodName><name>GoodTest</name><time>1500.0</time></successes>
+</result></runTestsResponse>
+</soapenv:Body></soapenv:Envelope>`), &output)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should report test times in seconds", func() {
+			report := NewTestCoverageReport(output)
+			Expect(report.Suites[0].Cases[0].Time).To(Equal(1.5))
+			Expect(report.Suites[0].Cases[1].Time).To(Equal(0.25))
+			Expect(report.Suites[0].Time).To(Equal(1.75))
+		})
+
+		It("should include failure messages and stack traces", func() {
+			failure := NewTestCoverageReport(output).Suites[0].Cases[1].Failure
+			Expect(failure.Message).To(Equal("Assertion Failed"))
+			Expect(failure.StackTrace).To(Equal("Class.BadTest.testIt: line 5"))
+		})
+	})
+})
@@ -0,0 +1,282 @@
+package lib
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Retrieving large amounts of metadata.  The retrieved zip file is streamed
+// to a temporary file and extracted one file at a time, and retrieves that
+// exceed the limits on the number of files or the size of a retrieve are
+// split into smaller retrieves.
+
+// Errors returned when a retrieve has too many files or is too large
+var retrieveLimitPattern = regexp.MustCompile(`(?i)limit_exceeded|exceeded the maximum|too many files|maximum size`)
+
+// Download the zip file of a retrieve request that has finished to a
+// temporary file.  The caller is responsible for removing the file.
+func (fm *ForceMetadata) downloadRetrieveZip(id string) (zipPath string, problems []string, err error) {
+	zipFile, err := ioutil.TempFile("", "force-retrieve")
+	if err != nil {
+		return
+	}
+	zipPath = zipFile.Name()
+	var rest []byte
+	err = fm.soapExecuteStream("checkRetrieveStatus", fmt.Sprintf("<id>%s</id>", id), func(body io.Reader) (err error) {
+		rest, err = decodeZipFileElement(body, zipFile)
+		return
+	})
+	if closeErr := zipFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(zipPath)
+		return
+	}
+	var status struct {
+		Status          string   `xml:"Body>checkRetrieveStatusResponse>result>status"`
+		ErrorStatusCode string   `xml:"Body>checkRetrieveStatusResponse>result>errorStatusCode"`
+		ErrorMessage    string   `xml:"Body>checkRetrieveStatusResponse>result>errorMessage"`
+		Problems        []string `xml:"Body>checkRetrieveStatusResponse>result>messages>problem"`
+	}
+	if err = xml.Unmarshal(rest, &status); err == nil && status.Status == "Failed" {
+		err = fmt.Errorf("%s: %s", status.ErrorStatusCode, status.ErrorMessage)
+	}
+	if err != nil {
+		os.Remove(zipPath)
+		return
+	}
+	problems = status.Problems
+	return
+}
+
+// Copy a checkRetrieveStatus response, decoding the contents of its zipFile
+// element to w.  The rest of the response is returned with an empty zipFile
+// element.
+func decodeZipFileElement(body io.Reader, w io.Writer) (rest []byte, err error) {
+	r := bufio.NewReader(body)
+	var b bytes.Buffer
+	for {
+		var chunk []byte
+		chunk, err = r.ReadBytes('>')
+		b.Write(chunk)
+		if err == io.EOF {
+			err = nil
+			rest = b.Bytes()
+			return
+		}
+		if err != nil {
+			return
+		}
+		if bytes.HasSuffix(chunk, []byte("<zipFile>")) {
+			break
+		}
+	}
+	if _, err = io.Copy(w, base64.NewDecoder(base64.StdEncoding, &untilReader{r: r, delim: '<'})); err != nil {
+		return
+	}
+	if _, err = io.Copy(&b, r); err != nil {
+		return
+	}
+	rest = b.Bytes()
+	return
+}
+
+// A reader that stops before a delimiter
+type untilReader struct {
+	r     *bufio.Reader
+	delim byte
+	done  bool
+}
+
+func (u *untilReader) Read(p []byte) (n int, err error) {
+	if u.done {
+		return 0, io.EOF
+	}
+	if u.r.Buffered() == 0 {
+		if _, err = u.r.Peek(1); err != nil {
+			return
+		}
+	}
+	buf, _ := u.r.Peek(u.r.Buffered())
+	end := bytes.IndexByte(buf, u.delim)
+	if end >= 0 {
+		buf = buf[:end]
+	}
+	n = copy(p, buf)
+	u.r.Discard(n)
+	if end >= 0 && n == end {
+		u.done = true
+		if n == 0 {
+			err = io.EOF
+		}
+	}
+	return
+}
+
+// Call a function with each file in a zip file, one at a time
+func extractZip(zipPath string, process func(name string, data []byte) error) (err error) {
+	zipReader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return
+	}
+	defer zipReader.Close()
+	for _, file := range zipReader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		var data []byte
+		if data, err = readZipFile(file); err != nil {
+			return
+		}
+		if err = process(file.Name, data); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func readZipFile(file *zip.File) (data []byte, err error) {
+	fd, err := file.Open()
+	if err != nil {
+		return
+	}
+	defer fd.Close()
+	return ioutil.ReadAll(fd)
+}
+
+// Retrieve the components in a query, writing the files to a directory as
+// they're extracted.  If process is given, it's called with each file's name
+// and contents, and returns the contents to write, or nil to skip the file.
+func (fm *ForceMetadata) RetrieveToDirectory(query ForceMetadataQuery, root string, process func(name string, data []byte) ([]byte, error)) (problems []string, err error) {
+	id, err := fm.startRetrieve(query)
+	if err != nil {
+		return
+	}
+	if err = fm.CheckStatus(id); err != nil {
+		return
+	}
+	zipPath, problems, err := fm.downloadRetrieveZip(id)
+	if err != nil {
+		return
+	}
+	defer os.Remove(zipPath)
+	err = extractZip(zipPath, func(name string, data []byte) (err error) {
+		name = strings.TrimPrefix(name, "unpackaged/")
+		if process != nil {
+			if data, err = process(name, data); err != nil || data == nil {
+				return
+			}
+		}
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return
+		}
+		return ioutil.WriteFile(file, data, 0644)
+	})
+	return
+}
+
+// Retrieve the components in a query to a directory like
+// RetrieveToDirectory, splitting the query into smaller retrieves if it
+// exceeds the limits on the number or size of the files in a retrieve.  The
+// query is split by metadata type, and then by component.
+func (fm *ForceMetadata) RetrieveSplitToDirectory(query ForceMetadataQuery, root string, process func(name string, data []byte) ([]byte, error)) (problems []string, err error) {
+	problems, err = fm.RetrieveToDirectory(query, root, process)
+	if err == nil || !isRetrieveLimitError(err) {
+		return
+	}
+	parts, splitErr := splitMetadataQuery(query, fm.ListMetadataNames)
+	if splitErr != nil || len(parts) < 2 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Retrieve too large (%s); splitting into smaller retrieves\n", err.Error())
+	problems, err = nil, nil
+	for _, part := range parts {
+		var partProblems []string
+		partProblems, err = fm.RetrieveSplitToDirectory(part, root, process)
+		problems = append(problems, partProblems...)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func isRetrieveLimitError(err error) bool {
+	return retrieveLimitPattern.MatchString(err.Error())
+}
+
+// Split a query in two, first by metadata type, and then by component.
+// Wildcards are replaced with the names of the components in the org so they
+// can be split.
+func splitMetadataQuery(query ForceMetadataQuery, listNames func(metadataType string) ([]string, error)) (parts []ForceMetadataQuery, err error) {
+	var elements ForceMetadataQuery
+	for _, element := range query {
+		for _, name := range element.Name {
+			elements = append(elements, ForceMetadataQueryElement{Name: []string{name}, Members: element.Members})
+		}
+	}
+	if len(elements) > 1 {
+		half := len(elements) / 2
+		parts = append(parts, elements[:half], elements[half:])
+		return
+	}
+	if len(elements) == 0 {
+		return
+	}
+
+	element := elements[0]
+	var members []string
+	seen := make(map[string]bool)
+	for _, member := range element.Members {
+		names := []string{member}
+		if member == "*" {
+			if names, err = listNames(element.Name[0]); err != nil {
+				return
+			}
+		}
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				members = append(members, name)
+			}
+		}
+	}
+	if len(members) < 2 {
+		return
+	}
+	half := len(members) / 2
+	parts = append(parts,
+		ForceMetadataQuery{{Name: element.Name, Members: members[:half]}},
+		ForceMetadataQuery{{Name: element.Name, Members: members[half:]}})
+	return
+}
+
+// Combine the package.xml files from several retrieves into one
+func MergePackageXml(packages ...[]byte) (merged []byte, err error) {
+	pb := NewFetchBuilder()
+	for _, data := range packages {
+		var p Package
+		if err = xml.Unmarshal(data, &p); err != nil {
+			return
+		}
+		for _, metaType := range p.Types {
+			for _, member := range metaType.Members {
+				pb.AddMetaToPackage(metaType.Name, member)
+			}
+		}
+	}
+	merged = pb.PackageXml()
+	return
+}
@@ -0,0 +1,82 @@
+package lib_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retrieve", func() {
+	Describe("DecodeZipFileElement", func() {
+		It("should decode the zip file and return the rest of the response", func() {
+			data := bytes.Repeat([]byte("zip data "), 2000)
+			body := `<soapenv:Envelope><soapenv:Body><checkRetrieveStatusResponse><result>` +
+				`<id>09S000000000001</id><messages><problem>Not found</problem></messages>` +
+				`<zipFile>` + base64.StdEncoding.EncodeToString(data) + `</zipFile>` +
+				`</result></checkRetrieveStatusResponse></soapenv:Body></soapenv:Envelope>`
+
+			var zipped bytes.Buffer
+			rest, err := DecodeZipFileElement(strings.NewReader(body), &zipped)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(zipped.Bytes()).To(Equal(data))
+			Expect(string(rest)).To(Equal(`<soapenv:Envelope><soapenv:Body><checkRetrieveStatusResponse><result>` +
+				`<id>09S000000000001</id><messages><problem>Not found</problem></messages>` +
+				`<zipFile></zipFile></result></checkRetrieveStatusResponse></soapenv:Body></soapenv:Envelope>`))
+		})
+	})
+
+	Describe("SplitMetadataQuery", func() {
+		listNames := func(metadataType string) ([]string, error) {
+			return []string{"Account", "Book__c", "Author__c"}, nil
+		}
+
+		It("should retrieve the types separately", func() {
+			parts, err := SplitMetadataQuery(ForceMetadataQuery{
+				{Name: []string{"ApexClass", "ApexPage"}, Members: []string{"*"}},
+				{Name: []string{"Profile"}, Members: []string{"*"}},
+			}, listNames)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parts).To(Equal([]ForceMetadataQuery{
+				{{Name: []string{"ApexClass"}, Members: []string{"*"}}},
+				{{Name: []string{"ApexPage"}, Members: []string{"*"}}, {Name: []string{"Profile"}, Members: []string{"*"}}},
+			}))
+		})
+		It("should split the members of a single type", func() {
+			parts, err := SplitMetadataQuery(ForceMetadataQuery{
+				{Name: []string{"CustomObject"}, Members: []string{"*", "Account", "Activity"}},
+			}, listNames)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parts).To(Equal([]ForceMetadataQuery{
+				{{Name: []string{"CustomObject"}, Members: []string{"Account", "Book__c"}}},
+				{{Name: []string{"CustomObject"}, Members: []string{"Author__c", "Activity"}}},
+			}))
+		})
+		It("should not split a single component", func() {
+			parts, _ := SplitMetadataQuery(ForceMetadataQuery{{Name: []string{"ApexClass"}, Members: []string{"MyClass"}}}, listNames)
+			Expect(parts).To(BeEmpty())
+		})
+	})
+
+	Describe("MergePackageXml", func() {
+		It("should combine the members of each type", func() {
+			first := NewFetchBuilder()
+			first.AddMetaToPackage("ApexClass", "A")
+			second := NewFetchBuilder()
+			second.AddMetaToPackage("ApexClass", "B")
+			second.AddMetaToPackage("Profile", "Admin")
+
+			merged, err := MergePackageXml(first.PackageXml(), second.PackageXml())
+			Expect(err).ToNot(HaveOccurred())
+			expected := NewFetchBuilder()
+			expected.AddMetaToPackage("ApexClass", "A")
+			expected.AddMetaToPackage("ApexClass", "B")
+			expected.AddMetaToPackage("Profile", "Admin")
+			Expect(string(merged)).To(Equal(string(expected.PackageXml())))
+		})
+	})
+})
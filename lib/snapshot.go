@@ -0,0 +1,339 @@
+package lib
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshots of the org's versions of the components in a deploy, taken
+// before deploying so the deploy can be rolled back.  A snapshot is a
+// directory containing the retrieved components, a package.xml, and a
+// destructiveChangesPost.xml that removes the components the deploy created.
+
+// Directory the snapshots are kept in
+const SnapshotDir = ".force-snapshots"
+
+// File in a snapshot directory describing the snapshot
+const snapshotInfoFile = "snapshot.json"
+
+type Snapshot struct {
+	Name          string    `json:"-"`
+	Dir           string    `json:"-"`
+	OrgId         string    `json:"orgId"`
+	Username      string    `json:"username"`
+	Created       time.Time `json:"created"`
+	Components    int       `json:"components"`
+	NewComponents int       `json:"newComponents"`
+}
+
+// Retrieve the org's versions of the components in a deploy's package.xml
+// and save them as a snapshot in a new directory within root
+func (f *Force) CreateSnapshot(root string, deployFiles ForceMetadataFiles) (snapshot Snapshot, err error) {
+	var p Package
+	if err = xml.Unmarshal(deployFiles["package.xml"], &p); err != nil {
+		err = fmt.Errorf("Could not read package.xml: %s", err.Error())
+		return
+	}
+	if p, err = expandWildcardMembers(p, deployFiles); err != nil {
+		return
+	}
+	existing, created, err := splitSnapshotComponents(p, f.Metadata.existingComponents)
+	if err != nil {
+		return
+	}
+	if err = addNewObjectFields(existing, &created, deployFiles, f.Metadata.existingComponents); err != nil {
+		return
+	}
+
+	snapshot.Created = time.Now()
+	snapshot.Name = snapshot.Created.Format("20060102-150405")
+	snapshot.Dir = filepath.Join(root, snapshot.Name)
+	if f.Credentials != nil && f.Credentials.UserInfo != nil {
+		snapshot.OrgId = f.Credentials.UserInfo.OrgId
+		snapshot.Username = f.Credentials.UserInfo.UserName
+	}
+	if err = os.MkdirAll(snapshot.Dir, 0755); err != nil {
+		return
+	}
+
+	files := make(ForceMetadataFiles)
+	if len(existing.Metadata) > 0 {
+		var problems []string
+		files, problems, err = f.Metadata.Retrieve(existingQuery(existing))
+		if err != nil {
+			return
+		}
+		for _, problem := range problems {
+			fmt.Fprintln(os.Stderr, problem)
+		}
+	}
+	files["package.xml"] = existing.PackageXml()
+	if len(created.Metadata) > 0 {
+		files["destructiveChangesPost.xml"] = created.PackageXml()
+	}
+	for name, data := range files {
+		file := filepath.Join(snapshot.Dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return
+		}
+		if err = ioutil.WriteFile(file, data, 0644); err != nil {
+			return
+		}
+	}
+
+	snapshot.Components = countMembers(existing)
+	snapshot.NewComponents = countMembers(created)
+	info, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return
+	}
+	err = ioutil.WriteFile(filepath.Join(snapshot.Dir, snapshotInfoFile), info, 0644)
+	return
+}
+
+func existingQuery(pb PackageBuilder) (query ForceMetadataQuery) {
+	var names []string
+	for name := range pb.Metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		query = append(query, ForceMetadataQueryElement{Name: []string{name}, Members: pb.Metadata[name].Members})
+	}
+	return
+}
+
+func countMembers(pb PackageBuilder) (count int) {
+	for _, metaType := range pb.Metadata {
+		count += len(metaType.Members)
+	}
+	return
+}
+
+// Replace wildcard members in a package with the components in the deploy's
+// files, so the components the deploy creates can be found.  Wildcards for
+// types whose components aren't in files of their own, like CustomField,
+// can't be expanded.
+func expandWildcardMembers(p Package, files ForceMetadataFiles) (expanded Package, err error) {
+	components := make(map[string][]string)
+	for _, name := range sortedFileNames(files) {
+		if isPackageManifest(name) {
+			continue
+		}
+		metaName, member := componentForFile(name)
+		if !containsString(components[metaName], member) {
+			components[metaName] = append(components[metaName], member)
+		}
+	}
+	expanded = p
+	expanded.Types = nil
+	for _, metaType := range p.Types {
+		if !containsString(metaType.Members, "*") {
+			expanded.Types = append(expanded.Types, metaType)
+			continue
+		}
+		if len(components[metaType.Name]) == 0 {
+			err = fmt.Errorf("Cannot take a snapshot of %s: the components matching its wildcard (*) member can't be determined from the deploy's files.  List the components in package.xml instead.", metaType.Name)
+			return
+		}
+		members := components[metaType.Name]
+		for _, member := range metaType.Members {
+			if member != "*" && !containsString(members, member) {
+				members = append(members, member)
+			}
+		}
+		expanded.Types = append(expanded.Types, MetaType{Name: metaType.Name, Members: members})
+	}
+	return
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Split the components in a package into those that already exist in the
+// org and those the deploy will create.
+func splitSnapshotComponents(p Package, existingComponents func(metaName string, members []string) (map[string]bool, error)) (existing PackageBuilder, created PackageBuilder, err error) {
+	existing = NewFetchBuilder()
+	created = NewFetchBuilder()
+	for _, metaType := range p.Types {
+		var found map[string]bool
+		if found, err = existingComponents(metaType.Name, metaType.Members); err != nil {
+			err = fmt.Errorf("Could not list %s: %s", metaType.Name, err.Error())
+			return
+		}
+		for _, member := range metaType.Members {
+			if found[member] {
+				existing.AddMetaToPackage(metaType.Name, member)
+			} else {
+				created.AddMetaToPackage(metaType.Name, member)
+			}
+		}
+	}
+	return
+}
+
+// Add the custom fields in the deploy's files for objects that already
+// exist to the components the deploy creates if they aren't in the org.
+// Fields are deployed within their object's file, so they aren't listed in
+// package.xml.  The fields of objects the deploy creates are deleted along
+// with the object.
+func addNewObjectFields(existing PackageBuilder, created *PackageBuilder, files ForceMetadataFiles, existingComponents func(metaName string, members []string) (map[string]bool, error)) (err error) {
+	var fields []string
+	for _, name := range sortedFileNames(files) {
+		metaName, object := componentForFile(name)
+		if metaName != "CustomObject" || !containsString(existing.Metadata[metaName].Members, object) {
+			continue
+		}
+		var objectFields struct {
+			Fields []struct {
+				FullName string `xml:"fullName"`
+			} `xml:"fields"`
+		}
+		if err = xml.Unmarshal(files[name], &objectFields); err != nil {
+			err = fmt.Errorf("Could not read %s: %s", name, err.Error())
+			return
+		}
+		for _, field := range objectFields.Fields {
+			member := object + "." + field.FullName
+			if !strings.HasSuffix(field.FullName, "__c") || containsString(fields, member) ||
+				containsString(existing.Metadata["CustomField"].Members, member) || containsString(created.Metadata["CustomField"].Members, member) {
+				continue
+			}
+			fields = append(fields, member)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	found, err := existingComponents("CustomField", fields)
+	if err != nil {
+		err = fmt.Errorf("Could not list CustomField: %s", err.Error())
+		return
+	}
+	for _, field := range fields {
+		if !found[field] {
+			created.AddMetaToPackage("CustomField", field)
+		}
+	}
+	return
+}
+
+// Get the names of the components of a type that exist in the org.
+// Components in folders are listed folder by folder.
+func (fm *ForceMetadata) existingComponents(metaName string, members []string) (found map[string]bool, err error) {
+	found = make(map[string]bool)
+	queries := []string{metaName}
+	if folderType, isFoldered := folderTypes[metaName]; isFoldered {
+		queries = []string{string(folderType) + "Folder"}
+		folders := make(map[string]bool)
+		for _, member := range members {
+			if strings.Contains(member, "/") {
+				folders[path.Dir(member)] = true
+			}
+		}
+		for folder := range folders {
+			queries = append(queries, metaName+":"+folder)
+		}
+	}
+	for _, query := range queries {
+		var properties []MDFileProperties
+		if properties, err = fm.listMetadataProperties(query); err != nil {
+			return
+		}
+		for _, p := range properties {
+			found[p.FullName] = true
+		}
+	}
+	return
+}
+
+// Take a snapshot before deploying, if requested by the deploy options
+func SnapshotBeforeDeploy(files ForceMetadataFiles, opts *ForceDeployOptions) (err error) {
+	if !opts.Snapshot || opts.CheckOnly {
+		return
+	}
+	force, _ := ActiveForce()
+	fmt.Println("Taking snapshot of components before deploying...")
+	snapshot, err := force.CreateSnapshot(SnapshotDir, files)
+	if err != nil {
+		return fmt.Errorf("Could not take snapshot: %s", err.Error())
+	}
+	fmt.Printf("Saved snapshot %s of %d components (%d new).  Roll back with: force rollback %s\n",
+		snapshot.Name, snapshot.Components, snapshot.NewComponents, snapshot.Name)
+	return
+}
+
+// Load a snapshot by name from the snapshot directory, or from a path
+func LoadSnapshot(name string) (snapshot Snapshot, err error) {
+	dir := name
+	if _, statErr := os.Stat(filepath.Join(SnapshotDir, name)); statErr == nil {
+		dir = filepath.Join(SnapshotDir, name)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, snapshotInfoFile))
+	if err != nil {
+		err = fmt.Errorf("No snapshot found at %s", dir)
+		return
+	}
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		return
+	}
+	snapshot.Name = filepath.Base(dir)
+	snapshot.Dir = dir
+	return
+}
+
+// List the snapshots in the snapshot directory, oldest first
+func ListSnapshots() (snapshots []Snapshot, err error) {
+	entries, err := ioutil.ReadDir(SnapshotDir)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if snapshot, loadErr := LoadSnapshot(filepath.Join(SnapshotDir, entry.Name())); loadErr == nil {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.Before(snapshots[j].Created)
+	})
+	return
+}
+
+// Read the files to deploy to roll back to a snapshot
+func (snapshot Snapshot) Files() (files ForceMetadataFiles, err error) {
+	files = make(ForceMetadataFiles)
+	err = filepath.Walk(snapshot.Dir, func(fpath string, f os.FileInfo, err error) error {
+		if err != nil || f.IsDir() || fpath == filepath.Join(snapshot.Dir, snapshotInfoFile) {
+			return err
+		}
+		data, err := ioutil.ReadFile(fpath)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(snapshot.Dir, fpath)
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	return
+}
@@ -0,0 +1,148 @@
+package lib_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshot", func() {
+	Describe("SplitSnapshotComponents", func() {
+		It("should separate existing components from those the deploy creates", func() {
+			p := Package{Types: []MetaType{
+				{Name: "ApexClass", Members: []string{"Existing", "New"}},
+				{Name: "CustomField", Members: []string{"Account.Existing__c", "Account.New__c"}},
+			}}
+			inOrg := map[string]bool{
+				"ApexClass:Existing":              true,
+				"CustomField:Account.Existing__c": true,
+			}
+			existing, created, err := SplitSnapshotComponents(p, func(metaName string, members []string) (map[string]bool, error) {
+				found := make(map[string]bool)
+				for _, member := range members {
+					found[member] = inOrg[metaName+":"+member]
+				}
+				return found, nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(existing.Metadata).To(HaveLen(2))
+			Expect(existing.Metadata["ApexClass"].Members).To(Equal([]string{"Existing"}))
+			Expect(existing.Metadata["CustomField"].Members).To(Equal([]string{"Account.Existing__c"}))
+			Expect(created.Metadata).To(HaveLen(2))
+			Expect(created.Metadata["ApexClass"].Members).To(Equal([]string{"New"}))
+			Expect(created.Metadata["CustomField"].Members).To(Equal([]string{"Account.New__c"}))
+		})
+	})
+
+	Describe("ExpandWildcardMembers", func() {
+		files := ForceMetadataFiles{
+			"package.xml":                []byte("<Package/>"),
+			"pages/Home.page":            []byte("<apex:page/>"),
+			"pages/Home.page-meta.xml":   []byte("<ApexPage/>"),
+			"pages/New.page":             []byte("<apex:page/>"),
+			"classes/Existing.cls":       []byte("class Existing {}"),
+			"destructiveChangesPost.xml": []byte("<Package/>"),
+		}
+
+		It("should list the deployed components of wildcard types", func() {
+			expanded, err := ExpandWildcardMembers(Package{Types: []MetaType{
+				{Name: "ApexClass", Members: []string{"Existing"}},
+				{Name: "ApexPage", Members: []string{"*"}},
+			}}, files)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(expanded.Types).To(Equal([]MetaType{
+				{Name: "ApexClass", Members: []string{"Existing"}},
+				{Name: "ApexPage", Members: []string{"Home", "New"}},
+			}))
+		})
+		It("should name documents with their extension", func() {
+			expanded, err := ExpandWildcardMembers(Package{Types: []MetaType{
+				{Name: "Document", Members: []string{"*"}},
+			}}, ForceMetadataFiles{
+				"package.xml":                        []byte("<Package/>"),
+				"documents/Shared/logo.png":          []byte("png"),
+				"documents/Shared/logo.png-meta.xml": []byte("<Document/>"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(expanded.Types).To(Equal([]MetaType{{Name: "Document", Members: []string{"Shared/logo.png"}}}))
+		})
+		It("should fail for wildcards that can't be expanded", func() {
+			_, err := ExpandWildcardMembers(Package{Types: []MetaType{
+				{Name: "CustomField", Members: []string{"*"}},
+			}}, files)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("AddNewObjectFields", func() {
+		It("should delete new custom fields of existing objects", func() {
+			existing := NewFetchBuilder()
+			existing.AddMetaToPackage("CustomObject", "Account")
+			existing.AddMetaToPackage("CustomField", "Account.Listed__c")
+			created := NewFetchBuilder()
+			created.AddMetaToPackage("CustomObject", "Book__c")
+			fields := func(name ...string) []byte {
+				object := "<CustomObject>"
+				for _, n := range name {
+					object += "<fields><fullName>" + n + "</fullName></fields>"
+				}
+				return []byte(object + "</CustomObject>")
+			}
+			files := ForceMetadataFiles{
+				"package.xml":            []byte("<Package/>"),
+				"objects/Account.object": fields("Name", "Existing__c", "Listed__c", "New__c"),
+				"objects/Book__c.object": fields("Title__c"),
+			}
+			var listed []string
+			err := AddNewObjectFields(existing, &created, files, func(metaName string, members []string) (map[string]bool, error) {
+				Expect(metaName).To(Equal("CustomField"))
+				listed = members
+				return map[string]bool{"Account.Existing__c": true}, nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(listed).To(Equal([]string{"Account.Existing__c", "Account.New__c"}))
+			Expect(created.Metadata["CustomField"].Members).To(Equal([]string{"Account.New__c"}))
+			Expect(existing.Metadata["CustomField"].Members).To(Equal([]string{"Account.Listed__c"}))
+		})
+	})
+
+	Describe("LoadSnapshot", func() {
+		var tempDir string
+
+		BeforeEach(func() {
+			tempDir, _ = ioutil.TempDir("", "snapshot-test")
+			os.MkdirAll(filepath.Join(tempDir, "classes"), 0755)
+			ioutil.WriteFile(filepath.Join(tempDir, "snapshot.json"), []byte(`{"orgId": "00D000000000001", "components": 1, "newComponents": 1}`), 0644)
+			ioutil.WriteFile(filepath.Join(tempDir, "package.xml"), []byte("<Package/>"), 0644)
+			ioutil.WriteFile(filepath.Join(tempDir, "destructiveChangesPost.xml"), []byte("<Package/>"), 0644)
+			ioutil.WriteFile(filepath.Join(tempDir, "classes", "A.cls"), []byte("public class A {}"), 0644)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should read the snapshot and its files", func() {
+			snapshot, err := LoadSnapshot(tempDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot.OrgId).To(Equal("00D000000000001"))
+			Expect(snapshot.Components).To(Equal(1))
+			Expect(snapshot.Name).To(Equal(filepath.Base(tempDir)))
+			files, err := snapshot.Files()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveLen(3))
+			Expect(files).To(HaveKey("classes/A.cls"))
+			Expect(files).To(HaveKey("destructiveChangesPost.xml"))
+			Expect(files).To(HaveKey("package.xml"))
+		})
+		It("should fail for a directory that isn't a snapshot", func() {
+			_, err := LoadSnapshot(filepath.Join(tempDir, "classes"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -5,7 +5,9 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"strings"
 )
 
@@ -74,7 +76,26 @@ func (s *Soap) ExecuteLogin(username, password string) (response []byte, err err
 
 }
 
-func (s *Soap) Execute(action, query string) (response []byte, err error) {
+func (s *Soap) post(action, query string) (res *http.Response, err error) {
+	req, err := httpRequest("POST", s.Endpoint, strings.NewReader(s.envelope(action, query)))
+	if err != nil {
+		return
+	}
+	req.Header.Add("Content-Type", "text/xml")
+	req.Header.Add("SOAPACtion", action)
+	res, err = doRequest(req)
+	if err != nil {
+		return
+	}
+	if res.StatusCode == 401 {
+		res.Body.Close()
+		err = errors.New("authorization expired, please run `force login`")
+	}
+	return
+}
+
+// Wrap the request body for an action in a SOAP envelope
+func (s *Soap) envelope(action, query string) string {
 	soap := `
 		<env:Envelope xmlns:xsd="http://www.w3.org/2001/XMLSchema" 
 		xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" 
@@ -94,23 +115,16 @@ func (s *Soap) Execute(action, query string) (response []byte, err error) {
 			</env:Body>
 		</env:Envelope>
 	`
-	rbody := fmt.Sprintf(soap, s.Namespace,
+	return fmt.Sprintf(soap, s.Namespace,
 		s.AccessToken, s.Header, action, s.Namespace, query, action)
-	req, err := httpRequest("POST", s.Endpoint, strings.NewReader(rbody))
-	if err != nil {
-		return
-	}
-	req.Header.Add("Content-Type", "text/xml")
-	req.Header.Add("SOAPACtion", action)
-	res, err := doRequest(req)
+}
+
+func (s *Soap) Execute(action, query string) (response []byte, err error) {
+	res, err := s.post(action, query)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
-	if res.StatusCode == 401 {
-		err = errors.New("authorization expired, please run `force login`")
-		return
-	}
 	response, err = ioutil.ReadAll(res.Body)
 	if err != nil {
 		return
@@ -123,6 +137,30 @@ func (s *Soap) Execute(action, query string) (response []byte, err error) {
 	return
 }
 
+// Execute an action, passing the response body to a function as it's read
+// instead of reading it into memory.  Error responses are read in full.
+func (s *Soap) ExecuteStream(action, query string, process func(body io.Reader) error) (err error) {
+	res, err := s.post(action, query)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		var response []byte
+		if response, err = ioutil.ReadAll(res.Body); err != nil {
+			return
+		}
+		if isSoapInvalidSessionError(response) {
+			return SessionExpiredError
+		}
+		if err = processError(response); err == nil {
+			err = fmt.Errorf("Unexpected response: %s", res.Status)
+		}
+		return
+	}
+	return process(res.Body)
+}
+
 func isSoapInvalidSessionError(body []byte) bool {
 	var soapError SoapError
 	xml.Unmarshal(body, &soapError)
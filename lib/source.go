@@ -0,0 +1,450 @@
+package lib
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Conversion between the metadata format used by the Metadata API and the
+// source format used by Salesforce DX projects.  In the source format, each
+// file that has no separate content is stored with a -meta.xml suffix, and
+// custom objects are decomposed into a directory per object with a file for
+// each field, list view, record type, etc.
+
+const metadataNamespace = "http://soap.sforce.com/2006/04/metadata"
+
+var packageManifestPattern = regexp.MustCompile(`^(package|destructiveChanges(Pre|Post)?)\.xml$`)
+
+type objectChild struct {
+	dir      string
+	metaName string
+	suffix   string
+}
+
+// Child metadata stored in its own file within a decomposed object's
+// directory.  The directory name matches the element name within the
+// CustomObject metadata.
+var objectChildren = []objectChild{
+	{dir: "businessProcesses", metaName: "BusinessProcess", suffix: "businessProcess"},
+	{dir: "compactLayouts", metaName: "CompactLayout", suffix: "compactLayout"},
+	{dir: "fieldSets", metaName: "FieldSet", suffix: "fieldSet"},
+	{dir: "fields", metaName: "CustomField", suffix: "field"},
+	{dir: "indexes", metaName: "Index", suffix: "index"},
+	{dir: "listViews", metaName: "ListView", suffix: "listView"},
+	{dir: "recordTypes", metaName: "RecordType", suffix: "recordType"},
+	{dir: "sharingReasons", metaName: "SharingReason", suffix: "sharingReason"},
+	{dir: "validationRules", metaName: "ValidationRule", suffix: "validationRule"},
+	{dir: "webLinks", metaName: "WebLink", suffix: "webLink"},
+}
+
+// Folder metadata is stored as Folder-meta.xml in the metadata format and
+// Folder.<suffix>-meta.xml in the source format
+var folderSuffixes = map[string]string{
+	"dashboards": "dashboardFolder",
+	"documents":  "documentFolder",
+	"email":      "emailFolder",
+	"reports":    "reportFolder",
+}
+
+type metadataElement struct {
+	XMLName xml.Name
+	Inner   []byte `xml:",innerxml"`
+}
+
+type metadataDocument struct {
+	XMLName  xml.Name
+	Elements []metadataElement `xml:",any"`
+}
+
+func objectChildForDir(dir string) (child objectChild, found bool) {
+	for _, c := range objectChildren {
+		if c.dir == dir {
+			return c, true
+		}
+	}
+	return
+}
+
+// Determine whether components in a metadata directory have a content file
+// alongside the -meta.xml file, which is the case in both formats
+func isContentDir(dir string) bool {
+	for _, mp := range getMetapaths() {
+		if mp.path == dir {
+			return mp.metaFile || mp.onlyFolder
+		}
+	}
+	return false
+}
+
+func isFolderSuffix(ext string) bool {
+	for _, suffix := range folderSuffixes {
+		if "."+suffix == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// Determine whether a directory is part of a Salesforce DX project, which
+// stores its metadata in the source format
+func IsSourceFormat(dir string) bool {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "sfdx-project.json")); err == nil {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// Determine whether a path is a source format -meta.xml file for metadata
+// that has no separate content file, e.g. profiles/Admin.profile-meta.xml.
+func isSourceMetaFile(fpath string) bool {
+	if !strings.HasSuffix(fpath, "-meta.xml") {
+		return false
+	}
+	spath := strings.TrimSuffix(fpath, "-meta.xml")
+	ext := filepath.Ext(spath)
+	if ext == "" {
+		return false
+	}
+	if isFolderSuffix(ext) {
+		return true
+	}
+	parentDir := filepath.Dir(spath)
+	return !isContentDir(filepath.Base(parentDir)) && !isContentDir(filepath.Base(filepath.Dir(parentDir)))
+}
+
+// Gets the metadata type and name of a file in a decomposed object directory
+func getSourceMetaForPath(fpath string) (metaName string, objectName string, found bool) {
+	parentDir := filepath.Dir(fpath)
+	parentName := filepath.Base(parentDir)
+	grandparentName := filepath.Base(filepath.Dir(parentDir))
+	fileName := filepath.Base(fpath)
+
+	if grandparentName == "objects" && strings.TrimSuffix(fileName, ".object") == parentName {
+		return "CustomObject", fileName, true
+	}
+	if child, ok := objectChildForDir(parentName); ok && filepath.Base(filepath.Dir(filepath.Dir(parentDir))) == "objects" {
+		return child.metaName, grandparentName + "." + fileName, true
+	}
+	return
+}
+
+// Convert files in the source format to the metadata format.  Files already
+// in the metadata format are returned unchanged.
+func ConvertToMetadataFormat(files ForceMetadataFiles) (converted ForceMetadataFiles, err error) {
+	converted = make(ForceMetadataFiles)
+	objects := make(map[string]*metadataDocument)
+	var objectNames []string
+	addObject := func(name string) *metadataDocument {
+		if _, found := objects[name]; !found {
+			objects[name] = &metadataDocument{XMLName: xml.Name{Local: "CustomObject"}}
+			objectNames = append(objectNames, name)
+		}
+		return objects[name]
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resources := make(map[string]string)
+	for _, name := range names {
+		fpath := filepath.ToSlash(name)
+		if strings.HasSuffix(fpath, ".resource-meta.xml") && path.Base(path.Dir(fpath)) == "staticresources" {
+			resource := strings.TrimSuffix(fpath, "-meta.xml")
+			if _, found := files[resource]; !found {
+				resources[strings.TrimSuffix(resource, ".resource")] = resource
+			}
+		}
+	}
+
+	for _, name := range names {
+		data := files[name]
+		fpath := filepath.ToSlash(name)
+		parts := strings.Split(fpath, "/")
+		n := len(parts)
+
+		if resource, found := resourceForContent(resources, fpath); found {
+			if path.Dir(fpath) == path.Dir(resource) {
+				converted[resource] = data
+			}
+			continue
+		}
+
+		if n >= 3 && parts[n-3] == "objects" && parts[n-1] == parts[n-2]+".object-meta.xml" {
+			var doc metadataDocument
+			if err = xml.Unmarshal(data, &doc); err != nil {
+				err = fmt.Errorf("Could not parse %s: %s", fpath, err.Error())
+				return
+			}
+			object := addObject(path.Join(path.Dir(path.Dir(fpath)), parts[n-2]+".object"))
+			object.Elements = append(object.Elements, doc.Elements...)
+			continue
+		}
+
+		if n >= 4 && parts[n-4] == "objects" {
+			if child, ok := objectChildForDir(parts[n-2]); ok && strings.HasSuffix(parts[n-1], "."+child.suffix+"-meta.xml") {
+				var doc metadataDocument
+				if err = xml.Unmarshal(data, &doc); err != nil {
+					err = fmt.Errorf("Could not parse %s: %s", fpath, err.Error())
+					return
+				}
+				object := addObject(path.Join(path.Dir(path.Dir(path.Dir(fpath))), parts[n-3]+".object"))
+				object.Elements = append(object.Elements, metadataElement{
+					XMLName: xml.Name{Local: child.dir},
+					Inner:   reindent(doc.innerXml(), 4),
+				})
+				continue
+			}
+		}
+
+		if isSourceMetaFile(fpath) {
+			spath := strings.TrimSuffix(fpath, "-meta.xml")
+			if _, found := files[spath]; !found {
+				ext := path.Ext(spath)
+				if isFolderSuffix(ext) {
+					fpath = strings.TrimSuffix(spath, ext) + "-meta.xml"
+				} else {
+					fpath = spath
+				}
+			}
+		}
+		converted[fpath] = data
+	}
+
+	for dir, resource := range resources {
+		if _, found := converted[resource]; found {
+			continue
+		}
+		var zipped []byte
+		zipped, err = zipFiles(files, dir+"/")
+		if err != nil {
+			return
+		}
+		if zipped != nil {
+			converted[resource] = zipped
+		}
+	}
+
+	for _, name := range objectNames {
+		object := objects[name]
+		sort.SliceStable(object.Elements, func(i, j int) bool {
+			return object.Elements[i].XMLName.Local < object.Elements[j].XMLName.Local
+		})
+		converted[name] = object.marshal()
+	}
+	return
+}
+
+// Find the static resource that a source format content file belongs to.
+// The content is either a single file with an extension matching its content
+// type, e.g. staticresources/jquery.js, or a directory of files that are
+// zipped when converted.
+func resourceForContent(resources map[string]string, fpath string) (resource string, found bool) {
+	if strings.HasSuffix(fpath, "-meta.xml") {
+		return
+	}
+	if resource, found = resources[strings.TrimSuffix(fpath, path.Ext(fpath))]; found {
+		return
+	}
+	for dir := path.Dir(fpath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if resource, found = resources[dir]; found {
+			return
+		}
+	}
+	return
+}
+
+func zipFiles(files ForceMetadataFiles, prefix string) (zipped []byte, err error) {
+	var names []string
+	for name := range files {
+		if strings.HasPrefix(filepath.ToSlash(name), prefix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+	buf := new(bytes.Buffer)
+	zipper := zip.NewWriter(buf)
+	for _, name := range names {
+		var w io.Writer
+		w, err = zipper.Create(strings.TrimPrefix(filepath.ToSlash(name), prefix))
+		if err != nil {
+			return
+		}
+		if _, err = w.Write(files[name]); err != nil {
+			return
+		}
+	}
+	if err = zipper.Close(); err != nil {
+		return
+	}
+	zipped = buf.Bytes()
+	return
+}
+
+// Convert files in the metadata format to the source format.  Files already
+// in the source format are returned unchanged.
+func ConvertToSourceFormat(files ForceMetadataFiles) (converted ForceMetadataFiles, err error) {
+	converted = make(ForceMetadataFiles)
+	for name, data := range files {
+		fpath := filepath.ToSlash(name)
+		parts := strings.Split(fpath, "/")
+		n := len(parts)
+
+		if n >= 2 && parts[n-2] == "objects" && path.Ext(fpath) == ".object" {
+			var decomposed ForceMetadataFiles
+			if decomposed, err = decomposeObject(fpath, data); err != nil {
+				return
+			}
+			for dname, ddata := range decomposed {
+				converted[dname] = ddata
+			}
+			continue
+		}
+
+		if strings.HasSuffix(fpath, "-meta.xml") {
+			spath := strings.TrimSuffix(fpath, "-meta.xml")
+			if suffix, ok := folderSuffixes[path.Base(path.Dir(spath))]; ok && path.Ext(spath) == "" {
+				fpath = spath + "." + suffix + "-meta.xml"
+			}
+			converted[fpath] = data
+			continue
+		}
+
+		if _, found := files[name+"-meta.xml"]; !found && !isPackageManifest(fpath) && isSourceMetaFile(fpath+"-meta.xml") && bytes.HasPrefix(bytes.TrimSpace(data), []byte("<?xml")) {
+			fpath = fpath + "-meta.xml"
+		}
+		converted[fpath] = data
+	}
+	return
+}
+
+// Build a package.xml listing the components in a set of files in the
+// metadata format
+func PackageXmlForFiles(files ForceMetadataFiles) []byte {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pb := NewFetchBuilder()
+	for _, name := range names {
+		fpath := filepath.FromSlash(name)
+		if strings.HasSuffix(fpath, "-meta.xml") || isPackageManifest(fpath) || filepath.Dir(fpath) == "." {
+			continue
+		}
+		metaName, fileName := getMetaForPath(fpath)
+		pb.AddMetaToPackage(metaName, strings.TrimSuffix(fileName, filepath.Ext(fileName)))
+	}
+	return pb.PackageXml()
+}
+
+func isPackageManifest(fpath string) bool {
+	return packageManifestPattern.MatchString(path.Base(fpath))
+}
+
+// Split a CustomObject into a directory containing the object's own metadata
+// and a file for each child component
+func decomposeObject(fpath string, data []byte) (files ForceMetadataFiles, err error) {
+	var doc metadataDocument
+	if err = xml.Unmarshal(data, &doc); err != nil {
+		err = fmt.Errorf("Could not parse %s: %s", fpath, err.Error())
+		return
+	}
+	files = make(ForceMetadataFiles)
+	objectName := strings.TrimSuffix(path.Base(fpath), ".object")
+	dir := path.Join(path.Dir(fpath), objectName)
+	object := metadataDocument{XMLName: xml.Name{Local: "CustomObject"}}
+	for _, element := range doc.Elements {
+		child, ok := objectChildForDir(element.XMLName.Local)
+		if !ok {
+			object.Elements = append(object.Elements, element)
+			continue
+		}
+		var fullName struct {
+			FullName string `xml:"fullName"`
+		}
+		inner := append(append([]byte("<x>"), element.Inner...), []byte("</x>")...)
+		if err = xml.Unmarshal(inner, &fullName); err != nil || fullName.FullName == "" {
+			err = fmt.Errorf("Could not find fullName of %s in %s", element.XMLName.Local, fpath)
+			return
+		}
+		childDoc := metadataDocument{XMLName: xml.Name{Local: child.metaName}}
+		childFile := path.Join(dir, child.dir, fullName.FullName+"."+child.suffix+"-meta.xml")
+		files[childFile] = childDoc.marshalInner(reindent(element.Inner, -4))
+	}
+	if len(object.Elements) > 0 {
+		files[path.Join(dir, objectName+".object-meta.xml")] = object.marshal()
+	}
+	return
+}
+
+// The inner XML of the document's root element
+func (doc metadataDocument) innerXml() []byte {
+	var inner bytes.Buffer
+	inner.WriteString("\n")
+	for _, element := range doc.Elements {
+		fmt.Fprintf(&inner, "    <%s>%s</%s>\n", element.XMLName.Local, element.Inner, element.XMLName.Local)
+	}
+	return inner.Bytes()
+}
+
+func (doc metadataDocument) marshal() []byte {
+	return doc.marshalInner(doc.innerXml())
+}
+
+func (doc metadataDocument) marshalInner(inner []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, "<%s xmlns=\"%s\">", doc.XMLName.Local, metadataNamespace)
+	b.Write(inner)
+	fmt.Fprintf(&b, "</%s>\n", doc.XMLName.Local)
+	return b.Bytes()
+}
+
+// Change the indentation of the elements within inner XML.  Lines of text
+// content are left alone so multi-line values such as formulas are not
+// modified.
+func reindent(inner []byte, delta int) []byte {
+	lines := bytes.Split(inner, []byte("\n"))
+	for i := 1; i < len(lines); i++ {
+		trimmed := bytes.TrimLeft(lines[i], " ")
+		isLast := i == len(lines)-1
+		if len(trimmed) == 0 && !isLast {
+			continue
+		}
+		if len(trimmed) > 0 && trimmed[0] != '<' {
+			continue
+		}
+		indent := len(lines[i]) - len(trimmed) + delta
+		if indent < 0 {
+			indent = 0
+		}
+		lines[i] = append(bytes.Repeat([]byte(" "), indent), trimmed...)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
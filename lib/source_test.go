@@ -0,0 +1,212 @@
+package lib_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const mdapiObject = `<?xml version="1.0" encoding="UTF-8"?>
+<CustomObject xmlns="http://soap.sforce.com/2006/04/metadata">
+    <fields>
+        <fullName>Author__c</fullName>
+        <label>Author</label>
+        <type>Text</type>
+    </fields>
+    <fields>
+        <fullName>Summary__c</fullName>
+        <formula>IF(ISBLANK(Author__c),
+  &apos;Anonymous&apos;,
+  Author__c)</formula>
+        <label>Summary</label>
+    </fields>
+    <label>Book</label>
+    <pluralLabel>Books</pluralLabel>
+</CustomObject>
+`
+
+const sourceObject = `<?xml version="1.0" encoding="UTF-8"?>
+<CustomObject xmlns="http://soap.sforce.com/2006/04/metadata">
+    <label>Book</label>
+    <pluralLabel>Books</pluralLabel>
+</CustomObject>
+`
+
+const sourceField = `<?xml version="1.0" encoding="UTF-8"?>
+<CustomField xmlns="http://soap.sforce.com/2006/04/metadata">
+    <fullName>Author__c</fullName>
+    <label>Author</label>
+    <type>Text</type>
+</CustomField>
+`
+
+const profile = `<?xml version="1.0" encoding="UTF-8"?>
+<Profile xmlns="http://soap.sforce.com/2006/04/metadata">
+    <custom>false</custom>
+</Profile>
+`
+
+var _ = Describe("Source", func() {
+	Describe("ConvertToSourceFormat", func() {
+		It("should decompose custom objects", func() {
+			files, err := ConvertToSourceFormat(ForceMetadataFiles{
+				"objects/Book__c.object": []byte(mdapiObject),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveLen(3))
+			Expect(string(files["objects/Book__c/Book__c.object-meta.xml"])).To(Equal(sourceObject))
+			Expect(string(files["objects/Book__c/fields/Author__c.field-meta.xml"])).To(Equal(sourceField))
+			Expect(string(files["objects/Book__c/fields/Summary__c.field-meta.xml"])).To(ContainSubstring("IF(ISBLANK(Author__c),\n  &apos;Anonymous&apos;,\n  Author__c)"))
+		})
+
+		It("should add a -meta.xml suffix to metadata without content", func() {
+			files, err := ConvertToSourceFormat(ForceMetadataFiles{
+				"package.xml":                []byte(`<?xml version="1.0" encoding="UTF-8"?><Package/>`),
+				"profiles/Admin.profile":     []byte(profile),
+				"classes/Test.cls":           []byte("class Test {}"),
+				"classes/Test.cls-meta.xml":  []byte(`<?xml version="1.0" encoding="UTF-8"?><ApexClass/>`),
+				"aura/myCmp/myCmp.cmp":       []byte("<aura:component/>"),
+				"aura/myCmp/myCmp.design":    []byte(`<?xml version="1.0" encoding="UTF-8"?><design:component/>`),
+				"reports/Sales-meta.xml":     []byte(`<?xml version="1.0" encoding="UTF-8"?><ReportFolder/>`),
+				"reports/Sales/Daily.report": []byte(`<?xml version="1.0" encoding="UTF-8"?><Report/>`),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveKey("package.xml"))
+			Expect(files).To(HaveKey("profiles/Admin.profile-meta.xml"))
+			Expect(files).To(HaveKey("classes/Test.cls"))
+			Expect(files).To(HaveKey("classes/Test.cls-meta.xml"))
+			Expect(files).To(HaveKey("aura/myCmp/myCmp.cmp"))
+			Expect(files).To(HaveKey("aura/myCmp/myCmp.design"))
+			Expect(files).To(HaveKey("reports/Sales.reportFolder-meta.xml"))
+			Expect(files).To(HaveKey("reports/Sales/Daily.report-meta.xml"))
+		})
+	})
+
+	Describe("ConvertToMetadataFormat", func() {
+		It("should recompose custom objects", func() {
+			source, _ := ConvertToSourceFormat(ForceMetadataFiles{
+				"objects/Book__c.object": []byte(mdapiObject),
+			})
+			files, err := ConvertToMetadataFormat(source)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+			Expect(string(files["objects/Book__c.object"])).To(Equal(mdapiObject))
+		})
+
+		It("should build an object from fields alone", func() {
+			files, err := ConvertToMetadataFormat(ForceMetadataFiles{
+				"objects/Account/fields/Author__c.field-meta.xml": []byte(sourceField),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(files["objects/Account.object"])).To(ContainSubstring("<fields>\n        <fullName>Author__c</fullName>"))
+		})
+
+		It("should remove the -meta.xml suffix from metadata without content", func() {
+			files, err := ConvertToMetadataFormat(ForceMetadataFiles{
+				"profiles/Admin.profile-meta.xml":     []byte(profile),
+				"classes/Test.cls":                    []byte("class Test {}"),
+				"classes/Test.cls-meta.xml":           []byte(`<?xml version="1.0" encoding="UTF-8"?><ApexClass/>`),
+				"reports/Sales.reportFolder-meta.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?><ReportFolder/>`),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveKey("profiles/Admin.profile"))
+			Expect(files).To(HaveKey("classes/Test.cls"))
+			Expect(files).To(HaveKey("classes/Test.cls-meta.xml"))
+			Expect(files).To(HaveKey("reports/Sales-meta.xml"))
+		})
+
+		It("should leave files in the metadata format unchanged", func() {
+			mdapi := ForceMetadataFiles{
+				"objects/Book__c.object":    []byte(mdapiObject),
+				"profiles/Admin.profile":    []byte(profile),
+				"classes/Test.cls":          []byte("class Test {}"),
+				"classes/Test.cls-meta.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?><ApexClass/>`),
+				"reports/Sales-meta.xml":    []byte(`<?xml version="1.0" encoding="UTF-8"?><ReportFolder/>`),
+			}
+			files, err := ConvertToMetadataFormat(mdapi)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(Equal(mdapi))
+		})
+
+		It("should convert static resources", func() {
+			files, err := ConvertToMetadataFormat(ForceMetadataFiles{
+				"staticresources/jquery.js":                []byte("jQuery"),
+				"staticresources/jquery.resource-meta.xml": []byte("<StaticResource/>"),
+				"staticresources/app/js/app.js":            []byte("app"),
+				"staticresources/app.resource-meta.xml":    []byte("<StaticResource/>"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveLen(4))
+			Expect(string(files["staticresources/jquery.resource"])).To(Equal("jQuery"))
+			Expect(files).To(HaveKey("staticresources/app.resource"))
+
+			r, err := zip.NewReader(bytes.NewReader(files["staticresources/app.resource"]), int64(len(files["staticresources/app.resource"])))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.File).To(HaveLen(1))
+			Expect(r.File[0].Name).To(Equal("js/app.js"))
+		})
+	})
+
+	Describe("PackageBuilder", func() {
+		var (
+			pb      PackageBuilder
+			tempDir string
+		)
+
+		BeforeEach(func() {
+			pb = NewPushBuilder()
+			tempDir, _ = ioutil.TempDir("", "source-test")
+			os.MkdirAll(tempDir+"/force-app/main/default/objects/Book__c/fields", 0755)
+			os.MkdirAll(tempDir+"/force-app/main/default/profiles", 0755)
+			ioutil.WriteFile(tempDir+"/force-app/main/default/objects/Book__c/Book__c.object-meta.xml", []byte(sourceObject), 0644)
+			ioutil.WriteFile(tempDir+"/force-app/main/default/objects/Book__c/fields/Author__c.field-meta.xml", []byte(sourceField), 0644)
+			ioutil.WriteFile(tempDir+"/force-app/main/default/profiles/Admin.profile-meta.xml", []byte(profile), 0644)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should add decomposed object files to the package", func() {
+			_, err := pb.AddFile(tempDir + "/force-app/main/default/objects/Book__c/fields/Author__c.field-meta.xml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pb.Metadata["CustomField"].Members).To(Equal([]string{"Book__c.Author__c"}))
+			Expect(pb.Files).To(HaveKey("objects/Book__c/fields/Author__c.field-meta.xml"))
+
+			_, err = pb.AddFile(tempDir + "/force-app/main/default/objects/Book__c/Book__c.object-meta.xml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pb.Metadata["CustomObject"].Members).To(Equal([]string{"Book__c"}))
+			Expect(pb.Files).To(HaveKey("objects/Book__c/Book__c.object-meta.xml"))
+		})
+
+		It("should add source format metadata without content to the package", func() {
+			_, err := pb.AddFile(tempDir + "/force-app/main/default/profiles/Admin.profile-meta.xml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pb.Metadata["Profile"].Members).To(Equal([]string{"Admin"}))
+		})
+
+		It("should deploy files in the metadata format", func() {
+			pb.AddFile(tempDir + "/force-app/main/default/objects/Book__c/fields/Author__c.field-meta.xml")
+			pb.AddFile(tempDir + "/force-app/main/default/profiles/Admin.profile-meta.xml")
+			files, err := pb.ForceMetadataFiles()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveKey("package.xml"))
+			Expect(files).To(HaveKey("objects/Book__c.object"))
+			Expect(files).To(HaveKey("profiles/Admin.profile"))
+			Expect(files).To(HaveLen(3))
+		})
+
+		It("should return an error for malformed source format files", func() {
+			ioutil.WriteFile(tempDir+"/force-app/main/default/objects/Book__c/fields/Author__c.field-meta.xml", []byte("<CustomField>"), 0644)
+			pb.AddFile(tempDir + "/force-app/main/default/objects/Book__c/fields/Author__c.field-meta.xml")
+			_, err := pb.ForceMetadataFiles()
+			Expect(err).To(MatchError(HavePrefix("Could not parse objects/Book__c/fields/Author__c.field-meta.xml")))
+		})
+	})
+})
@@ -11,18 +11,20 @@ type TestRunner interface {
 }
 
 type TestCoverage struct {
-	Log                       string   `xml:"Header>DebuggingInfo>debugLog"`
-	NumberRun                 int      `xml:"Body>runTestsResponse>result>numTestsRun"`
-	NumberFailures            int      `xml:"Body>runTestsResponse>result>numFailures"`
-	NumberLocations           []int    `xml:"Body>runTestsResponse>result>codeCoverage>numLocations"`
-	NumberLocationsNotCovered []int    `xml:"Body>runTestsResponse>result>codeCoverage>numLocationsNotCovered"`
-	Name                      []string `xml:"Body>runTestsResponse>result>codeCoverage>name"`
-	SMethodNames              []string `xml:"Body>runTestsResponse>result>successes>methodName"`
-	SClassNames               []string `xml:"Body>runTestsResponse>result>successes>name"`
-	FMethodNames              []string `xml:"Body>runTestsResponse>result>failures>methodName"`
-	FClassNames               []string `xml:"Body>runTestsResponse>result>failures>name"`
-	FMessage                  []string `xml:"Body>runTestsResponse>result>failures>message"`
-	FStackTrace               []string `xml:"Body>runTestsResponse>result>failures>stackTrace"`
+	Log                       string    `xml:"Header>DebuggingInfo>debugLog"`
+	NumberRun                 int       `xml:"Body>runTestsResponse>result>numTestsRun"`
+	NumberFailures            int       `xml:"Body>runTestsResponse>result>numFailures"`
+	NumberLocations           []int     `xml:"Body>runTestsResponse>result>codeCoverage>numLocations"`
+	NumberLocationsNotCovered []int     `xml:"Body>runTestsResponse>result>codeCoverage>numLocationsNotCovered"`
+	Name                      []string  `xml:"Body>runTestsResponse>result>codeCoverage>name"`
+	SMethodNames              []string  `xml:"Body>runTestsResponse>result>successes>methodName"`
+	SClassNames               []string  `xml:"Body>runTestsResponse>result>successes>name"`
+	STime                     []float64 `xml:"Body>runTestsResponse>result>successes>time"`
+	FMethodNames              []string  `xml:"Body>runTestsResponse>result>failures>methodName"`
+	FClassNames               []string  `xml:"Body>runTestsResponse>result>failures>name"`
+	FMessage                  []string  `xml:"Body>runTestsResponse>result>failures>message"`
+	FStackTrace               []string  `xml:"Body>runTestsResponse>result>failures>stackTrace"`
+	FTime                     []float64 `xml:"Body>runTestsResponse>result>failures>time"`
 }
 
 type TestNode struct {
@@ -0,0 +1,246 @@
+package lib
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Apex test suites, which are stored as ApexTestSuite records and their
+// TestSuiteMembership records in the Tooling API
+
+type TestSuite struct {
+	Id      string
+	Name    string
+	Classes []string
+}
+
+func soqlStringList(values []string) string {
+	var quoted []string
+	for _, v := range values {
+		quoted = append(quoted, fmt.Sprintf("'%s'", strings.Replace(v, "'", `\'`, -1)))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// List the test suites and their classes, sorted by name
+func (f *Force) ListTestSuites() (suites []TestSuite, err error) {
+	result, err := f.toolingQuery("SELECT Id, TestSuiteName FROM ApexTestSuite ORDER BY TestSuiteName")
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, record := range result.Records {
+		suite := TestSuite{}
+		suite.Id, _ = record["Id"].(string)
+		suite.Name, _ = record["TestSuiteName"].(string)
+		suites = append(suites, suite)
+		names = append(names, suite.Name)
+	}
+	if len(names) == 0 {
+		return
+	}
+	members, err := f.testSuiteMembers(names)
+	if err != nil {
+		return
+	}
+	for i := range suites {
+		suites[i].Classes = members[strings.ToLower(suites[i].Name)]
+	}
+	return
+}
+
+// Get the classes in each of the test suites, keyed by the suite name in
+// lower case since suite names are matched case-insensitively
+func (f *Force) testSuiteMembers(suites []string) (members map[string][]string, err error) {
+	members = make(map[string][]string)
+	soql := fmt.Sprintf("SELECT ApexClass.Name, ApexTestSuite.TestSuiteName FROM TestSuiteMembership WHERE ApexTestSuite.TestSuiteName IN (%s)", soqlStringList(suites))
+	result, err := f.toolingQuery(soql)
+	if err != nil {
+		return
+	}
+	for _, record := range result.Records {
+		suite := strings.ToLower(relatedField(record, "ApexTestSuite", "TestSuiteName"))
+		members[suite] = append(members[suite], relatedField(record, "ApexClass", "Name"))
+	}
+	for suite := range members {
+		sort.Strings(members[suite])
+	}
+	return
+}
+
+func relatedField(record ForceRecord, relationship string, field string) string {
+	related, _ := record[relationship].(map[string]interface{})
+	value, _ := related[field].(string)
+	return value
+}
+
+// Get the test classes in test suites.  An error is returned if a suite
+// doesn't exist.
+func (f *Force) TestSuiteClasses(suites []string) (classes []string, err error) {
+	ids, err := f.testSuiteIds(suites)
+	if err != nil {
+		return
+	}
+	members, err := f.testSuiteMembers(suites)
+	if err != nil {
+		return
+	}
+	return mergeTestSuiteClasses(suites, ids, members)
+}
+
+// Merge the classes of test suites into a sorted list without duplicates
+func mergeTestSuiteClasses(suites []string, ids map[string]string, members map[string][]string) (classes []string, err error) {
+	for _, suite := range suites {
+		if _, found := ids[strings.ToLower(suite)]; !found {
+			err = fmt.Errorf("Test suite %s not found", suite)
+			return
+		}
+		classes = append(classes, members[strings.ToLower(suite)]...)
+	}
+	classes = uniqueStrings(classes)
+	sort.Strings(classes)
+	return
+}
+
+// Get the ids of test suites, keyed by the suite name in lower case
+func (f *Force) testSuiteIds(suites []string) (ids map[string]string, err error) {
+	ids = make(map[string]string)
+	soql := fmt.Sprintf("SELECT Id, TestSuiteName FROM ApexTestSuite WHERE TestSuiteName IN (%s)", soqlStringList(suites))
+	result, err := f.toolingQuery(soql)
+	if err != nil {
+		return
+	}
+	for _, record := range result.Records {
+		name, _ := record["TestSuiteName"].(string)
+		ids[strings.ToLower(name)], _ = record["Id"].(string)
+	}
+	return
+}
+
+func (f *Force) testSuiteId(suite string) (id string, err error) {
+	ids, err := f.testSuiteIds([]string{suite})
+	if err != nil {
+		return
+	}
+	id, found := ids[strings.ToLower(suite)]
+	if !found {
+		err = fmt.Errorf("Test suite %s not found", suite)
+	}
+	return
+}
+
+// Create an empty test suite
+func (f *Force) CreateTestSuite(suite string) (id string, err error) {
+	result, err := f.CreateToolingRecord("ApexTestSuite", map[string]string{"TestSuiteName": suite})
+	if err != nil {
+		return
+	}
+	id = result.Id
+	return
+}
+
+// Delete a test suite.  Its classes are not deleted.
+func (f *Force) DeleteTestSuite(suite string) (err error) {
+	id, err := f.testSuiteId(suite)
+	if err != nil {
+		return
+	}
+	return f.DeleteToolingRecord("ApexTestSuite", id)
+}
+
+// Add test classes to a test suite.  Classes already in the suite are
+// skipped.  No classes are added if any of them don't exist.
+func (f *Force) AddTestSuiteClasses(suite string, classes []string) (err error) {
+	suiteId, err := f.testSuiteId(suite)
+	if err != nil {
+		return
+	}
+	members, err := f.testSuiteMembers([]string{suite})
+	if err != nil {
+		return
+	}
+	result, err := f.toolingQuery(fmt.Sprintf("SELECT Id, Name FROM ApexClass WHERE NamespacePrefix = null AND Name IN (%s)", soqlStringList(classes)))
+	if err != nil {
+		return
+	}
+	classIds := make(map[string]string)
+	for _, record := range result.Records {
+		name, _ := record["Name"].(string)
+		classIds[strings.ToLower(name)], _ = record["Id"].(string)
+	}
+	added, err := testSuiteClassIdsToAdd(classes, classIds, members[strings.ToLower(suite)])
+	if err != nil {
+		return
+	}
+	for _, classId := range added {
+		_, err = f.CreateToolingRecord("TestSuiteMembership", map[string]string{
+			"ApexTestSuiteId": suiteId,
+			"ApexClassId":     classId,
+		})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Get the ids of the classes to add to a test suite, skipping classes already
+// in the suite.  An error is returned if any of the classes don't exist so
+// the suite is left unchanged.
+func testSuiteClassIdsToAdd(classes []string, classIds map[string]string, members []string) (added []string, err error) {
+	for _, class := range classes {
+		classId, found := classIds[strings.ToLower(class)]
+		if !found {
+			added = nil
+			err = fmt.Errorf("Apex class %s not found", class)
+			return
+		}
+		if !containsFold(members, class) {
+			added = append(added, classId)
+		}
+	}
+	added = uniqueStrings(added)
+	return
+}
+
+// Remove test classes from a test suite.  No classes are removed if any of
+// them aren't in the suite.
+func (f *Force) RemoveTestSuiteClasses(suite string, classes []string) (err error) {
+	soql := fmt.Sprintf("SELECT Id, ApexClass.Name FROM TestSuiteMembership WHERE ApexTestSuite.TestSuiteName = '%s' AND ApexClass.Name IN (%s)",
+		strings.Replace(suite, "'", `\'`, -1), soqlStringList(classes))
+	result, err := f.toolingQuery(soql)
+	if err != nil {
+		return
+	}
+	removed, err := testSuiteMembershipIdsToRemove(suite, classes, result.Records)
+	if err != nil {
+		return
+	}
+	for _, id := range removed {
+		if err = f.DeleteToolingRecord("TestSuiteMembership", id); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Get the ids of the TestSuiteMembership records to delete to remove classes
+// from a test suite.  An error is returned if any of the classes aren't in the
+// suite so the suite is left unchanged.
+func testSuiteMembershipIdsToRemove(suite string, classes []string, memberships []ForceRecord) (removed []string, err error) {
+	inSuite := make(map[string]bool)
+	for _, record := range memberships {
+		inSuite[strings.ToLower(relatedField(record, "ApexClass", "Name"))] = true
+		id, _ := record["Id"].(string)
+		removed = append(removed, id)
+	}
+	for _, class := range classes {
+		if !inSuite[strings.ToLower(class)] {
+			removed = nil
+			err = fmt.Errorf("%s is not in test suite %s", class, suite)
+			return
+		}
+	}
+	return
+}
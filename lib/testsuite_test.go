@@ -0,0 +1,61 @@
+package lib_test
+
+import (
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testsuite", func() {
+	Describe("MergeTestSuiteClasses", func() {
+		ids := map[string]string{"smoke": "05F000000000001AAA", "regression": "05F000000000002AAA"}
+		members := map[string][]string{
+			"smoke":      {"ContactTest", "AccountTest"},
+			"regression": {"AccountTest", "OrderTest"},
+		}
+
+		It("should merge the classes of suites matched case-insensitively", func() {
+			classes, err := MergeTestSuiteClasses([]string{"Smoke", "REGRESSION"}, ids, members)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(classes).To(Equal([]string{"AccountTest", "ContactTest", "OrderTest"}))
+		})
+		It("should fail if a suite doesn't exist", func() {
+			_, err := MergeTestSuiteClasses([]string{"smoke", "Missing"}, ids, members)
+			Expect(err).To(MatchError("Test suite Missing not found"))
+		})
+	})
+
+	Describe("TestSuiteClassIdsToAdd", func() {
+		classIds := map[string]string{"accounttest": "01p000000000001AAA", "contacttest": "01p000000000002AAA"}
+
+		It("should skip classes already in the suite", func() {
+			added, err := TestSuiteClassIdsToAdd([]string{"AccountTest", "ContactTest", "accounttest"}, classIds, []string{"ContactTest"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(added).To(Equal([]string{"01p000000000001AAA"}))
+		})
+		It("should add nothing if a class doesn't exist", func() {
+			added, err := TestSuiteClassIdsToAdd([]string{"AccountTest", "MissingTest"}, classIds, nil)
+			Expect(err).To(MatchError("Apex class MissingTest not found"))
+			Expect(added).To(BeEmpty())
+		})
+	})
+
+	Describe("MembershipIdsToRemove", func() {
+		memberships := []ForceRecord{
+			{"Id": "01z000000000001AAA", "ApexClass": map[string]interface{}{"Name": "AccountTest"}},
+			{"Id": "01z000000000002AAA", "ApexClass": map[string]interface{}{"Name": "ContactTest"}},
+		}
+
+		It("should remove the memberships of the classes", func() {
+			removed, err := MembershipIdsToRemove("Smoke", []string{"accounttest", "ContactTest"}, memberships)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(Equal([]string{"01z000000000001AAA", "01z000000000002AAA"}))
+		})
+		It("should remove nothing if a class isn't in the suite", func() {
+			removed, err := MembershipIdsToRemove("Smoke", []string{"AccountTest", "OrderTest"}, memberships)
+			Expect(err).To(MatchError("OrderTest is not in test suite Smoke"))
+			Expect(removed).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,280 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Saving Apex and Visualforce through the Tooling API, which is much faster
+// than a Metadata API deploy for a few files.  Existing components are saved
+// together by adding members to a MetadataContainer and compiling it with a
+// ContainerAsyncRequest.  New components are created directly.
+
+type toolingType struct {
+	name      string
+	member    string
+	extension string
+}
+
+// The metadata types that can be saved through the Tooling API
+var toolingTypes = []toolingType{
+	{name: "ApexClass", member: "ApexClassMember", extension: ".cls"},
+	{name: "ApexComponent", member: "ApexComponentMember", extension: ".component"},
+	{name: "ApexPage", member: "ApexPageMember", extension: ".page"},
+	{name: "ApexTrigger", member: "ApexTriggerMember", extension: ".trigger"},
+}
+
+// A component to save through the Tooling API
+type ToolingFile struct {
+	Path string
+	Type string
+	Name string
+	Body string
+}
+
+// A problem saving a component through the Tooling API.  The line and
+// column are 0 if the problem isn't in the component's source.
+type ToolingSaveError struct {
+	Path    string
+	Type    string
+	Name    string
+	Line    int
+	Column  int
+	Problem string
+}
+
+// Read a file to save through the Tooling API.  A -meta.xml path is read from
+// its source file.
+func ReadToolingFile(fpath string) (file ToolingFile, err error) {
+	fpath = strings.TrimSuffix(fpath, "-meta.xml")
+	ext := filepath.Ext(fpath)
+	if file.Type = toolingTypeForPath(fpath); file.Type == "" {
+		err = fmt.Errorf("%s cannot be saved through the Tooling API; only Apex classes, triggers, pages, and components can", fpath)
+		return
+	}
+	body, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return
+	}
+	file.Path = fpath
+	file.Name = strings.TrimSuffix(filepath.Base(fpath), ext)
+	file.Body = string(body)
+	return
+}
+
+// Determine whether a file can be saved through the Tooling API
+func IsToolingFile(fpath string) bool {
+	return toolingTypeForPath(strings.TrimSuffix(fpath, "-meta.xml")) != ""
+}
+
+func toolingTypeForPath(fpath string) string {
+	for _, t := range toolingTypes {
+		if t.extension == filepath.Ext(fpath) {
+			return t.name
+		}
+	}
+	return ""
+}
+
+// Save Apex and Visualforce files through the Tooling API.  Compile errors
+// are returned as problems; err is only set if the files could not be saved
+// for another reason.
+func (f *Force) SaveToolingFiles(files []ToolingFile) (problems []ToolingSaveError, err error) {
+	ids, err := f.toolingIds(files)
+	if err != nil {
+		return
+	}
+	var existing []ToolingFile
+	for _, file := range files {
+		if _, found := ids[toolingKey(file.Type, file.Name)]; found {
+			existing = append(existing, file)
+			continue
+		}
+		if _, createErr := f.CreateToolingRecord(file.Type, newToolingRecord(file)); createErr != nil {
+			problems = append(problems, ToolingSaveError{Path: file.Path, Type: file.Type, Name: file.Name, Problem: createErr.Error()})
+		}
+	}
+	if len(existing) == 0 {
+		return
+	}
+
+	var containerProblems []ToolingSaveError
+	containerProblems, err = f.saveInMetadataContainer(existing, ids)
+	problems = append(problems, containerProblems...)
+	return
+}
+
+// Get the ids of the files' components that already exist, keyed by
+// toolingKey
+func (f *Force) toolingIds(files []ToolingFile) (ids map[string]string, err error) {
+	ids = make(map[string]string)
+	names := make(map[string][]string)
+	for _, file := range files {
+		names[file.Type] = append(names[file.Type], fmt.Sprintf("'%s'", strings.Replace(file.Name, "'", `\'`, -1)))
+	}
+	for metaType, typeNames := range names {
+		soql := fmt.Sprintf("SELECT Id, Name FROM %s WHERE NamespacePrefix = null AND Name IN (%s)", metaType, strings.Join(typeNames, ", "))
+		var result ForceQueryResult
+		result, err = f.toolingQuery(soql)
+		if err != nil {
+			return
+		}
+		for _, record := range result.Records {
+			ids[toolingKey(metaType, fmt.Sprintf("%s", record["Name"]))] = fmt.Sprintf("%s", record["Id"])
+		}
+	}
+	return
+}
+
+// Component names are matched case-insensitively, as in the org
+func toolingKey(metaType string, name string) string {
+	return metaType + ":" + strings.ToLower(name)
+}
+
+var triggerObjectPattern = regexp.MustCompile(`(?is)^\s*trigger\s+\w+\s+on\s+(\w+)`)
+
+// Get the sObject a trigger is on from its header, e.g. "trigger X on Account"
+func triggerObject(body string) string {
+	body = stripApexComments(body)
+	if m := triggerObjectPattern.FindStringSubmatch(body); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+var apexCommentPattern = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+
+func stripApexComments(body string) string {
+	return apexCommentPattern.ReplaceAllString(body, "")
+}
+
+// The fields used to create a new component
+func newToolingRecord(file ToolingFile) map[string]string {
+	switch file.Type {
+	case "ApexPage", "ApexComponent":
+		return map[string]string{
+			"Name":        file.Name,
+			"MasterLabel": file.Name,
+			"Markup":      file.Body,
+		}
+	case "ApexTrigger":
+		return map[string]string{
+			"Body":          file.Body,
+			"TableEnumOrId": triggerObject(file.Body),
+		}
+	default:
+		return map[string]string{"Body": file.Body}
+	}
+}
+
+func (f *Force) saveInMetadataContainer(files []ToolingFile, ids map[string]string) (problems []ToolingSaveError, err error) {
+	container, err := f.CreateToolingRecord("MetadataContainer", map[string]string{
+		"Name": fmt.Sprintf("force-%d", time.Now().UnixNano()),
+	})
+	if err != nil {
+		return
+	}
+	// Deleting the container deletes its members
+	defer f.DeleteToolingRecord("MetadataContainer", container.Id)
+
+	for _, file := range files {
+		member := ""
+		for _, t := range toolingTypes {
+			if t.name == file.Type {
+				member = t.member
+			}
+		}
+		_, err = f.CreateToolingRecord(member, map[string]string{
+			"Body":                file.Body,
+			"ContentEntityId":     ids[toolingKey(file.Type, file.Name)],
+			"MetadataContainerId": container.Id,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	request, err := f.CreateToolingRecord("ContainerAsyncRequest", map[string]string{
+		"MetadataContainerId": container.Id,
+	})
+	if err != nil {
+		return
+	}
+	result, err := f.waitForContainerAsyncRequest(request.Id)
+	if err != nil {
+		return
+	}
+	problems = result.problems(files)
+	if len(problems) == 0 && result.State != "Completed" {
+		err = fmt.Errorf("Save %s: %s", strings.ToLower(result.State), result.ErrorMsg)
+	}
+	return
+}
+
+type containerAsyncRequest struct {
+	State         string
+	ErrorMsg      string
+	DeployDetails struct {
+		ComponentFailures []struct {
+			FullName      string
+			ComponentType string
+			LineNumber    int
+			ColumnNumber  int
+			Problem       string
+		}
+	}
+}
+
+// Convert the request's component failures to problems with the files
+func (r containerAsyncRequest) problems(files []ToolingFile) (problems []ToolingSaveError) {
+	for _, failure := range r.DeployDetails.ComponentFailures {
+		problem := ToolingSaveError{
+			Type:    failure.ComponentType,
+			Name:    failure.FullName,
+			Line:    failure.LineNumber,
+			Column:  failure.ColumnNumber,
+			Problem: failure.Problem,
+		}
+		for _, file := range files {
+			if file.Type == failure.ComponentType && strings.EqualFold(file.Name, failure.FullName) {
+				problem.Path = file.Path
+			}
+		}
+		problems = append(problems, problem)
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Path < problems[j].Path
+	})
+	return
+}
+
+func (f *Force) waitForContainerAsyncRequest(id string) (result containerAsyncRequest, err error) {
+	aurl := fmt.Sprintf("%s/services/data/%s/tooling/sobjects/ContainerAsyncRequest/%s", f.Credentials.InstanceUrl, apiVersion, url.PathEscape(id))
+	for {
+		var body []byte
+		body, err = f.httpGet(aurl)
+		if err != nil {
+			return
+		}
+		if err = json.Unmarshal(body, &result); err != nil {
+			return
+		}
+		switch result.State {
+		case "Queued", "":
+			time.Sleep(time.Second)
+		case "Completed", "Failed", "Error", "Aborted", "Invalidated":
+			return
+		default:
+			err = errors.New("Unknown save state: " + result.State)
+			return
+		}
+	}
+}
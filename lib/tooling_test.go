@@ -0,0 +1,93 @@
+package lib_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/ForceCLI/force/lib"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tooling", func() {
+	Describe("ReadToolingFile", func() {
+		var (
+			tempDir string
+			class   string
+		)
+
+		BeforeEach(func() {
+			tempDir, _ = ioutil.TempDir("", "tooling-test")
+			os.MkdirAll(filepath.Join(tempDir, "classes"), 0755)
+			class = filepath.Join(tempDir, "classes", "MyClass.cls")
+			ioutil.WriteFile(class, []byte("public class MyClass {}"), 0644)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should read a class from its file or meta file", func() {
+			expected := ToolingFile{Path: class, Type: "ApexClass", Name: "MyClass", Body: "public class MyClass {}"}
+			for _, fpath := range []string{class, class + "-meta.xml"} {
+				file, err := ReadToolingFile(fpath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(file).To(Equal(expected))
+			}
+		})
+		It("should reject unsupported files", func() {
+			_, err := ReadToolingFile(filepath.Join(tempDir, "objects", "Account.object"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ContainerAsyncRequest", func() {
+		It("should report problems with the paths of the files", func() {
+			var result ContainerAsyncRequest
+			err := json.Unmarshal([]byte(`{
+				"State": "Failed",
+				"ErrorMsg": null,
+				"DeployDetails": {
+					"componentFailures": [{
+						"componentType": "ApexClass",
+						"fullName": "MyClass",
+						"lineNumber": 3,
+						"columnNumber": 12,
+						"problem": "Variable does not exist: x",
+						"problemType": "Error"
+					}]
+				}
+			}`), &result)
+			Expect(err).ToNot(HaveOccurred())
+			files := []ToolingFile{{Path: "src/classes/MyClass.cls", Type: "ApexClass", Name: "MyClass"}}
+			Expect(ContainerAsyncRequestProblems(result, files)).To(Equal([]ToolingSaveError{{
+				Path:    "src/classes/MyClass.cls",
+				Type:    "ApexClass",
+				Name:    "MyClass",
+				Line:    3,
+				Column:  12,
+				Problem: "Variable does not exist: x",
+			}}))
+		})
+		It("should match file names that differ in case", func() {
+			var result ContainerAsyncRequest
+			err := json.Unmarshal([]byte(`{"DeployDetails": {"componentFailures": [{"componentType": "ApexClass", "fullName": "MyClass"}]}}`), &result)
+			Expect(err).ToNot(HaveOccurred())
+			files := []ToolingFile{{Path: "src/classes/myclass.cls", Type: "ApexClass", Name: "myclass"}}
+			problems := ContainerAsyncRequestProblems(result, files)
+			Expect(problems).To(HaveLen(1))
+			Expect(problems[0].Path).To(Equal("src/classes/myclass.cls"))
+		})
+	})
+
+	Describe("NewToolingRecord", func() {
+		It("should set the object of a trigger", func() {
+			body := "/* Keeps trigger logic on Contact out of here */\ntrigger AccountTrigger on Account (before insert) {}"
+			record := NewToolingRecord(ToolingFile{Type: "ApexTrigger", Name: "AccountTrigger", Body: body})
+			Expect(record).To(Equal(map[string]string{"Body": body, "TableEnumOrId": "Account"}))
+		})
+	})
+})